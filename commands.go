@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bcsherma/egosat/egosat"
+)
+
+// parseCommand parses the flags of a subcommand, which may come before or after
+// its positional arguments, and returns the positional arguments.
+func parseCommand(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			return positional
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// simplifyCommand implements `egosat simplify in.cnf -o out.cnf -map map.txt`.
+// It preprocesses the formula and writes the simplified formula, over variables
+// renumbered from 1, along with the data needed by extendCommand to lift its
+// models back to the original variables.
+func simplifyCommand(args []string) {
+	fs := flag.NewFlagSet("simplify", flag.ExitOnError)
+	out := fs.String("o", "", "file receiving the simplified formula")
+	mapFile := fs.String("map", "", "file receiving the reconstruction data")
+	cover := fs.Bool("cover", false, "also remove covered clauses")
+	probe := fs.Int("probe", 0, "propagation ticks spent probing failed literals, 0 disables")
+	bva := fs.Bool("bva", false, "also replace repeated clause patterns by fresh variables")
+	files := parseCommand(fs, args)
+	if len(files) != 1 || *out == "" || *mapFile == "" {
+		fmt.Fprintln(os.Stderr, "usage: egosat simplify in.cnf -o out.cnf -map map.txt")
+		os.Exit(1)
+	}
+	solver := parseFormula(files[0])
+	nClauses := solver.NumClauses()
+	params := egosat.SolverParams{
+		VarActivityDecay:    0.8,
+		ClauseActivityDecay: 0.999,
+		Substitute:          true,
+		Unhide:              true,
+		Subsume:             true,
+		Block:               true,
+		Cover:               *cover,
+		Eliminate:           true,
+		ProbeBudget:         *probe,
+		AddVariables:        *bva,
+	}
+	var clauses, extension [][]egosat.Lit
+	if solver.Simplify(params) == egosat.LFALSE {
+		clauses = [][]egosat.Lit{{}}
+	} else {
+		clauses = solver.Clauses()
+		extension = solver.Extension()
+	}
+	// Unit clauses are kept in the reconstruction data, and the variables
+	// of the other clauses are renumbered in increasing order.
+	var units []egosat.Lit
+	renamed := make(map[int]int)
+	var old []int
+	j := 0
+	for _, c := range clauses {
+		if len(c) == 1 {
+			units = append(units, c[0])
+			continue
+		}
+		clauses[j] = c
+		j++
+		for _, l := range c {
+			if _, ok := renamed[variable(l)]; !ok {
+				renamed[variable(l)] = 0
+				old = append(old, variable(l))
+			}
+		}
+	}
+	clauses = clauses[:j]
+	sort.Ints(old)
+	for i, v := range old {
+		renamed[v] = i + 1
+	}
+	w := createFile(*out)
+	fmt.Fprintf(w, "p cnf %d %d\n", len(old), len(clauses))
+	for _, c := range clauses {
+		for _, l := range c {
+			fmt.Fprintf(w, "%d ", rename(l, renamed[variable(l)]))
+		}
+		fmt.Fprintln(w, 0)
+	}
+	closeFile(w)
+	w = createFile(*mapFile)
+	fmt.Fprintf(w, "c egosat reconstruction data of %s\n", files[0])
+	for i, v := range old {
+		fmt.Fprintf(w, "m %d %d\n", i+1, v)
+	}
+	for _, l := range units {
+		fmt.Fprintf(w, "u %d\n", l)
+	}
+	for _, c := range extension {
+		fmt.Fprint(w, "e")
+		for _, l := range c {
+			fmt.Fprintf(w, " %d", l)
+		}
+		fmt.Fprintln(w, " 0")
+	}
+	closeFile(w)
+	fmt.Printf("c simplified %d variables and %d clauses to %d variables and %d clauses\n",
+		solver.NumVariables(), nClauses, len(old), len(clauses))
+}
+
+// extendCommand implements `egosat extend in.cnf map.txt solution.txt`. It
+// lifts the solution of a formula written by simplifyCommand to a model of the
+// original formula, which it checks and prints in the DIMACS output format.
+func extendCommand(args []string) {
+	fs := flag.NewFlagSet("extend", flag.ExitOnError)
+	files := parseCommand(fs, args)
+	if len(files) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: egosat extend in.cnf map.txt solution.txt")
+		os.Exit(1)
+	}
+	reader := createFormulaReader(files[0])
+	nVars, _ := parseProblemLine(reader)
+	var renamed []int
+	var units []egosat.Lit
+	var extension [][]egosat.Lit
+	for _, fields := range readLines(files[1]) {
+		switch fields[0] {
+		case "m":
+			renamed = append(renamed, parseInt(fields[2]))
+		case "u":
+			units = append(units, egosat.Lit(parseInt(fields[1])))
+		case "e":
+			extension = append(extension, parseLits(fields[1:]))
+		}
+	}
+	// Variables added by the simplification come after the original ones.
+	n := nVars
+	for _, v := range renamed {
+		if v > n {
+			n = v
+		}
+	}
+	for _, l := range units {
+		if variable(l) > n {
+			n = variable(l)
+		}
+	}
+	model := make([]egosat.Lit, n)
+	for v := 1; v <= n; v++ {
+		model[v-1] = egosat.Lit(-v)
+	}
+	for _, l := range units {
+		model[variable(l)-1] = l
+	}
+	sat := false
+	for _, fields := range readLines(files[2]) {
+		switch {
+		case fields[0] == "s" || fields[0] == "SAT" || fields[0] == "UNSAT":
+			sat = fields[len(fields)-1] == "SATISFIABLE" || fields[0] == "SAT"
+		case fields[0] == "v":
+			fields = fields[1:]
+			fallthrough
+		default:
+			for _, l := range parseLits(fields) {
+				if variable(l) > len(renamed) {
+					panic(fmt.Errorf("unknown variable in solution: %d", l))
+				}
+				model[renamed[variable(l)-1]-1] = rename(l, renamed[variable(l)-1])
+			}
+		}
+	}
+	if !sat {
+		fmt.Println("s UNSATISFIABLE")
+		return
+	}
+	egosat.ExtendModel(model, extension)
+	for {
+		line, err := reader.ReadString('\n')
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] == "c" || fields[0] == "%" {
+			if err != nil {
+				break
+			}
+			continue
+		}
+		if c := parseLits(fields); !satisfies(model, c) {
+			panic(fmt.Errorf("extended model falsifies clause %v", c))
+		}
+		if err != nil {
+			break
+		}
+	}
+	fmt.Println("s SATISFIABLE")
+	fmt.Print("v ")
+	for _, l := range model[:nVars] {
+		fmt.Printf("%d ", l)
+	}
+	fmt.Print("0\n")
+}
+
+// variable returns the variable of the literal.
+func variable(l egosat.Lit) int {
+	if l < 0 {
+		return int(-l)
+	}
+	return int(l)
+}
+
+// rename returns the literal of the given variable with the sign of l.
+func rename(l egosat.Lit, v int) egosat.Lit {
+	if l < 0 {
+		return egosat.Lit(-v)
+	}
+	return egosat.Lit(v)
+}
+
+// satisfies reports whether the model, given as the true literal of every
+// variable, satisfies the clause.
+func satisfies(model []egosat.Lit, c []egosat.Lit) bool {
+	for _, l := range c {
+		if model[variable(l)-1] == l {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLits parses literals up to the first 0.
+func parseLits(fields []string) []egosat.Lit {
+	var lits []egosat.Lit
+	for _, f := range fields {
+		l := parseInt(f)
+		if l == 0 {
+			break
+		}
+		lits = append(lits, egosat.Lit(l))
+	}
+	return lits
+}
+
+// parseInt parses an integer, panicking if it is malformed.
+func parseInt(s string) int {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		panic(err)
+	}
+	return i
+}
+
+// readLines returns the fields of the non-empty lines of the file which are not
+// comments.
+func readLines(filename string) [][]string {
+	f, err := os.Open(filename)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	var lines [][]string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<30)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 && fields[0] != "c" {
+			lines = append(lines, fields)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		panic(err)
+	}
+	return lines
+}
+
+// bufferedFile is a file written through a buffer.
+type bufferedFile struct {
+	*bufio.Writer
+	file *os.File
+}
+
+// createFile creates the file for buffered writing.
+func createFile(filename string) bufferedFile {
+	f, err := os.Create(filename)
+	if err != nil {
+		panic(err)
+	}
+	return bufferedFile{bufio.NewWriter(f), f}
+}
+
+// closeFile flushes and closes the file.
+func closeFile(w bufferedFile) {
+	if err := w.Flush(); err != nil {
+		panic(err)
+	}
+	if err := w.file.Close(); err != nil {
+		panic(err)
+	}
+}
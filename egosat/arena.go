@@ -0,0 +1,183 @@
+package egosat
+
+import "math"
+
+// Every clause in the arena starts with a header of clauseHeader words. The
+// first word holds the size of the clause and its flags, the second its LBD and
+// the third its activity. When a clause is relocated by garbage collection, the
+// second word is overwritten with the offset of the clause in the new arena.
+const (
+	clauseHeader  = 3
+	learntFlag    = 1 << 31 // Marks learnt clauses
+	deletedFlag   = 1 << 30 // Marks clauses which have been deleted
+	relocatedFlag = 1 << 29 // Marks clauses which have been moved to a new arena
+	vivifiedFlag  = 1 << 28 // Marks learnt clauses which have been vivified
+	sizeMask      = vivifiedFlag - 1
+)
+
+// gcFraction is the fraction of the arena which may be wasted by deleted
+// clauses before it is compacted.
+const gcFraction = 0.2
+
+// The clauseArena struct stores clauses contiguously in a slice of words. A
+// clause is referenced by the offset of its header. The first word of the arena
+// is never used, so that the zero Clause refers to no clause.
+type clauseArena struct {
+	mem    []uint32 // Headers and literals of all clauses
+	wasted int      // Number of words occupied by deleted clauses
+}
+
+// createArena creates an arena with room for the given number of words.
+func createArena(capacity int) clauseArena {
+	return clauseArena{mem: make([]uint32, 1, capacity+1)}
+}
+
+// alloc stores a new clause with the given literals and returns its reference.
+func (a *clauseArena) alloc(lits []Lit, learnt bool) Clause {
+	c := Clause(len(a.mem))
+	header := uint32(len(lits))
+	if learnt {
+		header |= learntFlag
+	}
+	a.mem = append(a.mem, header, 0, 0)
+	for _, l := range lits {
+		a.mem = append(a.mem, uint32(l))
+	}
+	return c
+}
+
+// size returns the number of literals of the clause.
+func (a *clauseArena) size(c Clause) int { return int(a.mem[c] & sizeMask) }
+
+// learnt reports whether the clause is learnt.
+func (a *clauseArena) learnt(c Clause) bool { return a.mem[c]&learntFlag != 0 }
+
+// deleted reports whether the clause has been deleted.
+func (a *clauseArena) deleted(c Clause) bool { return a.mem[c]&deletedFlag != 0 }
+
+// lit returns the i-th literal of the clause.
+func (a *clauseArena) lit(c Clause, i int) Lit {
+	return Lit(a.mem[int(c)+clauseHeader+i])
+}
+
+// setLit replaces the i-th literal of the clause.
+func (a *clauseArena) setLit(c Clause, i int, l Lit) {
+	a.mem[int(c)+clauseHeader+i] = uint32(l)
+}
+
+// swap exchanges the i-th and j-th literals of the clause.
+func (a *clauseArena) swap(c Clause, i, j int) {
+	i, j = int(c)+clauseHeader+i, int(c)+clauseHeader+j
+	a.mem[i], a.mem[j] = a.mem[j], a.mem[i]
+}
+
+// appendLits appends the literals of the clause to the given slice.
+func (a *clauseArena) appendLits(lits []Lit, c Clause) []Lit {
+	for i := 0; i < a.size(c); i++ {
+		lits = append(lits, a.lit(c, i))
+	}
+	return lits
+}
+
+// vivified reports whether the clause has been vivified.
+func (a *clauseArena) vivified(c Clause) bool { return a.mem[c]&vivifiedFlag != 0 }
+
+// setVivified marks the clause as vivified.
+func (a *clauseArena) setVivified(c Clause) { a.mem[c] |= vivifiedFlag }
+
+// lbd returns the LBD of the clause.
+func (a *clauseArena) lbd(c Clause) int { return int(a.mem[c+1]) }
+
+// setLBD sets the LBD of the clause.
+func (a *clauseArena) setLBD(c Clause, lbd int) { a.mem[c+1] = uint32(lbd) }
+
+// activity returns the activity of the clause.
+func (a *clauseArena) activity(c Clause) float32 {
+	return math.Float32frombits(a.mem[c+2])
+}
+
+// setActivity sets the activity of the clause.
+func (a *clauseArena) setActivity(c Clause, activity float32) {
+	a.mem[c+2] = math.Float32bits(activity)
+}
+
+// shrink removes all but the first n literals of the clause.
+func (a *clauseArena) shrink(c Clause, n int) {
+	a.wasted += a.size(c) - n
+	a.mem[c] = a.mem[c]&^sizeMask | uint32(n)
+}
+
+// free marks the clause as deleted. Its words are reclaimed by the next
+// garbage collection.
+func (a *clauseArena) free(c Clause) {
+	a.mem[c] |= deletedFlag
+	a.wasted += clauseHeader + a.size(c)
+}
+
+// relocate copies the clause into the new arena unless it has already been
+// moved there, and returns its reference in the new arena.
+func (a *clauseArena) relocate(c Clause, to *clauseArena) Clause {
+	if a.mem[c]&relocatedFlag != 0 {
+		return Clause(a.mem[c+1])
+	}
+	moved := Clause(len(to.mem))
+	to.mem = append(to.mem, a.mem[c:int(c)+clauseHeader+a.size(c)]...)
+	a.mem[c] |= relocatedFlag
+	a.mem[c+1] = uint32(moved)
+	return moved
+}
+
+// collectGarbage compacts the clause arena once deleted clauses waste more
+// than gcFraction of it.
+func (solver *Solver) collectGarbage() {
+	if float64(solver.arena.wasted) > gcFraction*float64(len(solver.arena.mem)) {
+		solver.compactArena()
+	}
+}
+
+// compactArena moves the clauses in the order of the clause lists into the
+// memory of the previous arena if it is large enough, and updates watchers and
+// reasons to the new references. Stale watchers, of deleted clauses or of
+// literals removed from their clause, are dropped. Reasons referring to deleted
+// clauses, which only remain for literals assigned at decision level 0, are
+// cleared.
+func (solver *Solver) compactArena() {
+	from := &solver.arena
+	to := clauseArena{mem: solver.spare[:0]}
+	if cap(to.mem) < len(from.mem)-from.wasted {
+		to = createArena(len(from.mem) - from.wasted)
+	} else {
+		to.mem = append(to.mem, 0)
+	}
+	solver.binaryConflict = from.relocate(solver.binaryConflict, &to)
+	solver.binaryReason = from.relocate(solver.binaryReason, &to)
+	for _, clauses := range [][]Clause{solver.clauses, solver.learntClauses} {
+		for i, c := range clauses {
+			clauses[i] = from.relocate(c, &to)
+		}
+	}
+	for i, ws := range solver.watcherLists {
+		j := 0
+		for _, w := range ws {
+			if !from.deleted(w.clause) && w.clause.watches(solver, indexLit(i)) {
+				ws[j] = watcher{from.relocate(w.clause, &to), w.blocker}
+				j++
+			}
+		}
+		solver.watcherLists[i] = ws[:j]
+	}
+	for _, l := range solver.trail {
+		r := &solver.reasons[l.variable()]
+		if r.clause == noClause {
+			continue
+		}
+		if from.deleted(r.clause) {
+			r.clause = noClause
+		} else {
+			r.clause = from.relocate(r.clause, &to)
+		}
+	}
+	solver.spare = from.mem
+	solver.arena = to
+	solver.stats.NumCollections++
+}
@@ -0,0 +1,63 @@
+package egosat
+
+import "testing"
+
+func TestArena(t *testing.T) {
+	a := createArena(0)
+	c := a.alloc([]Lit{1, -2, 3}, true)
+	if c == noClause || a.size(c) != 3 || !a.learnt(c) || a.deleted(c) {
+		t.Fail()
+	}
+	if a.lit(c, 1) != Lit(-2) {
+		t.Fail()
+	}
+	a.swap(c, 0, 1)
+	a.setLBD(c, 2)
+	a.setActivity(c, 1.5)
+	if a.lit(c, 0) != Lit(-2) || a.lbd(c) != 2 || a.activity(c) != 1.5 {
+		t.Fail()
+	}
+	a.shrink(c, 2)
+	if lits := a.appendLits(nil, c); len(lits) != 2 || lits[1] != Lit(1) || a.wasted != 1 {
+		t.Fail()
+	}
+	a.free(c)
+	if !a.deleted(c) || a.wasted != clauseHeader+3 {
+		t.Fail()
+	}
+}
+
+func TestCollectGarbage(t *testing.T) {
+	solver := CreateSolver(10, 5)
+	solver.AddClause([]Lit{1, 2, 3})
+	c1 := solver.addLearnt([]Lit{-1, 2, 4})
+	c2 := solver.addLearnt([]Lit{-3, -4, 5})
+	solver.assume(-2)
+	solver.assume(4)
+	solver.assume(3)
+	solver.propagate()
+	if solver.reasons[5].clause != c2 {
+		t.Fail()
+	}
+	solver.deleteClause(c1)
+	solver.learntClauses = solver.learntClauses[1:]
+	solver.collectGarbage()
+	if solver.stats.NumCollections != 1 || solver.arena.wasted != 0 {
+		t.Fail()
+	}
+	// The remaining clauses, watchers and reasons refer to the new arena
+	c2 = solver.learntClauses[0]
+	if solver.reasons[5].clause != c2 || solver.arena.lit(c2, 0) != Lit(5) {
+		t.Fail()
+	}
+	for _, ws := range solver.watcherLists {
+		for _, w := range ws {
+			if w.clause != solver.clauses[0] && w.clause != c2 {
+				t.Fail()
+			}
+		}
+	}
+	if confl := solver.propagate(); confl != noClause {
+		t.Fail()
+	}
+}
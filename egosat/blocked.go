@@ -0,0 +1,214 @@
+package egosat
+
+// These constants bound the work of blocked and covered clause elimination.
+const (
+	blockOccLimit = 64      // Occurrences of the negated literal above which a literal is not tried
+	coverLimit    = 64      // Literals a clause may grow to by covered literal addition
+	blockBudget   = 1 << 24 // Literals visited before giving up
+)
+
+// The blocker struct holds the state of a blocked clause elimination pass. It
+// uses the occurrence lists of an eliminator for the irredundant clauses, and
+// separate occurrence lists for the learnt clauses, which are never removed
+// but must also resolve tautologically with a blocked clause.
+type blocker struct {
+	*eliminator
+	learnts      [][]Lit // Literals of the learnt clauses
+	learntOccurs [][]int // Learnt clauses containing each literal, indexed by Lit.index
+	queued       []bool  // Marks the clauses waiting in the queue
+	queue        []int   // Clauses which may be blocked
+	covered      []Lit   // Literals of the clause extended by covered literal addition
+	steps        [][]Lit // Clauses kept for extending the model, witness first
+	resolved     []int   // Clauses with a non-tautological resolvent on the literal
+}
+
+// block runs blocked clause elimination at decision level 0. A clause is blocked
+// on one of its literals if all its resolvents on this literal are tautologies,
+// in which case it is removed and kept for extending the model. With Cover,
+// covered clause elimination first extends each clause with the literals
+// contained in all the clauses giving a non-tautological resolvent on one of
+// its literals, and removes it if the extended clause is blocked or a
+// tautology. It returns false if the formula was found unsatisfiable.
+func (solver *Solver) block() bool {
+	if solver.propagate() != noClause {
+		return false
+	}
+	if !solver.simplifyAll() {
+		return false
+	}
+	e := solver.createEliminator()
+	if e.unsat {
+		return false
+	}
+	e.budget = blockBudget
+	b := &blocker{
+		eliminator:   e,
+		learntOccurs: make([][]int, 2*solver.NumVariables()),
+		queued:       make([]bool, len(e.clauses)),
+	}
+	for _, c := range solver.learntClauses {
+		lits := solver.arena.appendLits(nil, c)
+		for _, l := range lits {
+			b.learntOccurs[l.index()] = append(b.learntOccurs[l.index()], len(b.learnts))
+		}
+		b.learnts = append(b.learnts, lits)
+	}
+	for id := len(e.clauses) - 1; id >= 0; id-- {
+		b.queue = append(b.queue, id)
+		b.queued[id] = true
+	}
+	for len(b.queue) > 0 && e.budget > 0 && solver.checkInterrupt() {
+		id := b.queue[len(b.queue)-1]
+		b.queue = b.queue[:len(b.queue)-1]
+		b.queued[id] = false
+		if e.clauses[id] != nil && b.eliminable(id) {
+			b.removeBlocked(id)
+		}
+	}
+	return solver.rebuild(e.clauses)
+}
+
+// eliminable reports whether the clause is blocked or, with Cover, covered.
+// The clauses to keep for extending the model are left in steps: the clauses
+// of the covered literal additions followed by the blocked clause, unless the
+// extended clause is a tautology.
+func (b *blocker) eliminable(id int) bool {
+	b.covered = append(b.covered[:0], b.clauses[id]...)
+	b.steps = b.steps[:0]
+	for _, l := range b.covered {
+		b.marks[l.index()] = true
+	}
+	defer func() {
+		for _, l := range b.covered {
+			b.marks[l.index()] = false
+		}
+	}()
+	for i := 0; i < len(b.covered); i++ {
+		l := b.covered[i]
+		if !b.resolve(l) {
+			continue
+		}
+		if len(b.resolved) == 0 {
+			b.steps = append(b.steps, witnessFirst(b.covered, l.variable()))
+			return true
+		}
+		if !b.solver.params.Cover || len(b.covered) >= coverLimit {
+			continue
+		}
+		added, taut := b.cover(l)
+		if taut {
+			return true
+		}
+		if added {
+			// The new literals may make resolvents on earlier literals
+			// tautological.
+			i = -1
+		}
+	}
+	return false
+}
+
+// resolve collects in resolved the clauses containing the negation of the
+// literal which give a non-tautological resolvent with the marked clause. It
+// returns false if the literal has too many occurrences, or if a learnt clause
+// gives a non-tautological resolvent.
+func (b *blocker) resolve(l Lit) bool {
+	b.resolved = b.resolved[:0]
+	neg := b.live(l.negation())
+	if len(neg)+len(b.learntOccurs[l.negation().index()]) > blockOccLimit {
+		return false
+	}
+	for _, id := range neg {
+		if !b.tautology(b.clauses[id], l) {
+			b.resolved = append(b.resolved, id)
+		}
+	}
+	for _, id := range b.learntOccurs[l.negation().index()] {
+		if !b.tautology(b.learnts[id], l) {
+			// Flipping the literal when extending the model could falsify
+			// the learnt clause, which is not kept for covering.
+			return false
+		}
+	}
+	return true
+}
+
+// tautology reports whether the resolvent on the literal of the marked clause
+// and the given clause, which contains its negation, is a tautology.
+func (b *blocker) tautology(lits []Lit, l Lit) bool {
+	b.budget -= len(lits)
+	for _, k := range lits {
+		if k != l.negation() && b.marks[k.negation().index()] {
+			return true
+		}
+	}
+	return false
+}
+
+// cover adds to the marked clause the literals other than the negation of the
+// literal contained in all the clauses of resolved, recording the clause before
+// the addition with the literal as witness. It reports whether literals were
+// added and whether the extended clause is a tautology.
+func (b *blocker) cover(l Lit) (added, taut bool) {
+	first := b.clauses[b.resolved[0]]
+	n := len(b.covered)
+	for _, k := range first {
+		if k == l.negation() || b.marks[k.index()] {
+			continue
+		}
+		common := true
+		for _, id := range b.resolved[1:] {
+			if !containsLit(b.clauses[id], k) {
+				common = false
+				break
+			}
+		}
+		b.budget -= len(b.resolved)
+		if !common {
+			continue
+		}
+		if len(b.covered) == n {
+			b.steps = append(b.steps, witnessFirst(b.covered, l.variable()))
+		}
+		if b.marks[k.negation().index()] {
+			taut = true
+		}
+		b.marks[k.index()] = true
+		b.covered = append(b.covered, k)
+	}
+	return len(b.covered) > n, taut
+}
+
+// containsLit reports whether the clause contains the literal.
+func containsLit(lits []Lit, l Lit) bool {
+	for _, k := range lits {
+		if k == l {
+			return true
+		}
+	}
+	return false
+}
+
+// removeBlocked removes a clause found eliminable, keeping its steps for
+// extending the model. The clauses containing the negation of one of its
+// literals are queued again, as they may have become blocked.
+func (b *blocker) removeBlocked(id int) {
+	solver := b.solver
+	if len(b.covered) > len(b.clauses[id]) {
+		solver.stats.NumCovered++
+	} else {
+		solver.stats.NumBlocked++
+	}
+	solver.extension = append(solver.extension, b.steps...)
+	solver.proofDelete(b.clauses[id])
+	lits := b.clauses[id]
+	b.remove(id)
+	for _, l := range lits {
+		for _, other := range b.live(l.negation()) {
+			if !b.queued[other] {
+				b.queued[other] = true
+				b.queue = append(b.queue, other)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package egosat
+
+import "testing"
+
+func TestBlock(t *testing.T) {
+	solver := CreateSolver(3, 3)
+	solver.AddClause([]Lit{1, 2, 3})
+	solver.AddClause([]Lit{-1, -2})
+	solver.AddClause([]Lit{-3, 2})
+	solver.params.Block = true
+	if !solver.block() {
+		t.FailNow()
+	}
+	if solver.stats.NumBlocked == 0 || solver.stats.NumCovered != 0 || len(solver.extension) == 0 {
+		t.Fail()
+	}
+}
+
+func TestCover(t *testing.T) {
+	solver := CreateSolver(3, 3)
+	solver.AddClause([]Lit{1, 2})
+	solver.AddClause([]Lit{-1, 3})
+	solver.AddClause([]Lit{-2, -3})
+	solver.params.Block = true
+	if !solver.block() || solver.stats.NumBlocked != 0 {
+		t.FailNow()
+	}
+	solver.params.Cover = true
+	if !solver.block() || solver.stats.NumCovered != 1 {
+		t.Fail()
+	}
+}
+
+func TestBlockModel(t *testing.T) {
+	r := createRandom(5)
+	for n := 0; n < 20; n++ {
+		var clauses [][]Lit
+		solver := CreateSolver(120, 40)
+		for i := 0; i < 120; i++ {
+			clause := make([]Lit, 2+r.intn(3))
+			for j := range clause {
+				clause[j] = Lit(r.intn(40) + 1)
+				if r.float() < 0.5 {
+					clause[j] = clause[j].negation()
+				}
+			}
+			clauses = append(clauses, clause)
+			solver.AddClause(append([]Lit(nil), clause...))
+		}
+		params := SolverParams{
+			MaxConflict:         1000,
+			MaxLearnts:          100,
+			VarActivityDecay:    0.95,
+			ClauseActivityDecay: 0.999,
+			Block:               true,
+			Cover:               true,
+		}
+		res := solver.Search(params)
+		for res == LNULL {
+			res = solver.Search(params)
+		}
+		if res != LTRUE {
+			continue
+		}
+		model := solver.Model()
+		for _, c := range clauses {
+			satisfied := false
+			for _, l := range c {
+				satisfied = satisfied || model[l.variable()-1] == l
+			}
+			if !satisfied {
+				t.Fatalf("model falsifies %v", c)
+			}
+		}
+	}
+}
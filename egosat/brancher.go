@@ -0,0 +1,103 @@
+package egosat
+
+// The brancher interface is implemented by decision heuristics. The solver
+// notifies its brancher whenever a literal takes part in a conflict, whenever
+// propagation completes and whenever a variable is unassigned, and asks it for
+// the next literal to branch on.
+type brancher interface {
+	// bump is invoked for every literal which takes part in conflict analysis.
+	bump(lit Lit)
+	// learnt is invoked with every learnt clause, before backtracking.
+	learnt(lits []Lit)
+	// decay is invoked once after every conflict, once all literals involved in
+	// the conflict have been bumped.
+	decay(factor float64)
+	// propagated is invoked whenever unit propagation completes, and reports
+	// whether propagation ended in a conflict.
+	propagated(conflict bool)
+	// unassign is invoked whenever a literal is removed from the trail.
+	unassign(lit Lit)
+	// next returns a literal of a decidable variable to branch on. It is only
+	// invoked when at least one variable is decidable.
+	next() Lit
+	// peek returns a literal of the variable which next would branch on,
+	// without consuming it. It is only invoked when at least one variable is
+	// decidable.
+	peek() Lit
+	// priority returns the rank of the literal in the heuristic, where
+	// literals of higher priority are branched on first.
+	priority(lit Lit) float64
+	// activate is invoked when the brancher becomes active again at decision
+	// level 0, after the solver used another brancher in the meantime.
+	activate()
+}
+
+// Heuristic identifies a decision heuristic which can be selected through
+// SolverParams.
+type Heuristic int
+
+const (
+	// VSIDS selects the Variable State Independent Decaying Sum heuristic.
+	VSIDS = Heuristic(iota)
+	// VMTF selects the Variable Move To Front heuristic.
+	VMTF
+	// LRB selects the Learning Rate Branching heuristic.
+	LRB
+	// CHB selects the Conflict History-Based Branching heuristic.
+	CHB
+)
+
+// String returns the name of the heuristic.
+func (h Heuristic) String() string {
+	switch h {
+	case VSIDS:
+		return "vsids"
+	case VMTF:
+		return "vmtf"
+	case LRB:
+		return "lrb"
+	case CHB:
+		return "chb"
+	}
+	return "unknown"
+}
+
+// The noHooks struct provides empty implementations of the brancher
+// notifications which are not needed by every heuristic.
+type noHooks struct{}
+
+func (noHooks) learnt(lits []Lit)        {}
+func (noHooks) propagated(conflict bool) {}
+
+// createBrancher creates a brancher implementing the given heuristic for the
+// variables of the given solver.
+func createBrancher(solver *Solver, h Heuristic) brancher {
+	switch h {
+	case VSIDS:
+		return createVSIDS(solver)
+	case VMTF:
+		return createVMTF(solver)
+	case LRB:
+		return createLRB(solver)
+	case CHB:
+		return createCHB(solver)
+	}
+	panic("unknown decision heuristic")
+}
+
+// useHeuristic makes the brancher of the current mode active, creating it if
+// it does not implement the given heuristic. A brancher which was inactive is
+// activated, since it missed the unassignments made in the meantime.
+func (solver *Solver) useHeuristic(h Heuristic) {
+	mode := 0
+	if solver.stable {
+		mode = 1
+	}
+	if solver.branchers[mode] == nil || solver.heuristics[mode] != h {
+		solver.branchers[mode] = createBrancher(solver, h)
+		solver.heuristics[mode] = h
+	} else if solver.brancher != solver.branchers[mode] {
+		solver.branchers[mode].activate()
+	}
+	solver.brancher = solver.branchers[mode]
+}
@@ -0,0 +1,256 @@
+package egosat
+
+import "sort"
+
+// These constants bound the work of bounded variable addition.
+const (
+	bvaBudget  = 1 << 24 // Literals visited before giving up
+	bvaMaxVars = 1 << 16 // Variables which may be added by one pass
+)
+
+// The adder struct holds the state of a bounded variable addition pass, which
+// uses the occurrence lists of an eliminator.
+type adder struct {
+	*eliminator
+	lits    []Lit   // Literals of the current pattern, the first one being the seed
+	matched []int   // Clauses of the seed matching the current pattern
+	pairs   []match // Candidate literals to extend the pattern with
+	counts  []int   // Number of clauses matching each candidate, indexed by Lit.index
+}
+
+// A match records that a clause of the seed matches a clause with the same
+// literals, except that the seed is replaced by the other literal.
+type match struct {
+	lit Lit
+	id  int
+}
+
+// addVariables runs bounded variable addition at decision level 0. It looks for
+// a set of literals L and a set of clauses C such that every clause c ∨ l, with
+// c in C and l in L, belongs to the formula, and replaces these |L|·|C| clauses
+// by the |L|+|C| clauses x ∨ l and c ∨ ¬x for a fresh variable x, hidden from
+// the model. Every model of the new formula satisfies the replaced clauses, so
+// no model extension is needed. It returns false if the formula was found
+// unsatisfiable.
+func (solver *Solver) addVariables() bool {
+	if solver.propagate() != noClause {
+		return false
+	}
+	if !solver.simplifyAll() {
+		return false
+	}
+	e := solver.createEliminator()
+	if e.unsat {
+		return false
+	}
+	e.budget = bvaBudget
+	a := &adder{eliminator: e, counts: make([]int, 2*solver.NumVariables())}
+	var queue []int
+	queued := make([]bool, 2*solver.NumVariables())
+	for i := range queued {
+		if len(e.live(indexLit(i))) > 1 {
+			queue = append(queue, i)
+			queued[i] = true
+		}
+	}
+	// The queue is used as a stack, and literals with more occurrences are
+	// more likely to start a pattern.
+	sort.SliceStable(queue, func(i, j int) bool {
+		return len(e.occurs[queue[i]]) < len(e.occurs[queue[j]])
+	})
+	added := 0
+	for len(queue) > 0 && e.budget > 0 && added < bvaMaxVars && solver.checkInterrupt() {
+		i := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		queued[i] = false
+		if !a.findPattern(indexLit(i)) {
+			continue
+		}
+		a.replace()
+		added++
+		for len(queued) < len(a.counts) {
+			queued = append(queued, false)
+		}
+		for _, l := range a.lits {
+			if !queued[l.index()] {
+				queued[l.index()] = true
+				queue = append(queue, l.index())
+			}
+		}
+	}
+	if added > 0 {
+		solver.resetBranchers()
+	}
+	return solver.rebuild(e.clauses)
+}
+
+// reduction returns the number of clauses removed by replacing a pattern of
+// the given numbers of literals and clauses.
+func reduction(lits, clauses int) int {
+	return lits*clauses - lits - clauses
+}
+
+// findPattern greedily extends the pattern of the seed literal, one literal at
+// a time, while this increases the reduction, and reports whether the final
+// pattern reduces the number of clauses.
+func (a *adder) findPattern(seed Lit) bool {
+	a.lits = append(a.lits[:0], seed)
+	a.matched = append(a.matched[:0], a.live(seed)...)
+	for {
+		a.matchClauses(seed)
+		best := Lit(0)
+		for _, m := range a.pairs {
+			if best == 0 || a.counts[m.lit.index()] > a.counts[best.index()] {
+				best = m.lit
+			}
+		}
+		n := 0
+		if best != 0 {
+			n = a.counts[best.index()]
+		}
+		for _, m := range a.pairs {
+			a.counts[m.lit.index()] = 0
+		}
+		if best == 0 || reduction(len(a.lits)+1, n) <= reduction(len(a.lits), len(a.matched)) {
+			break
+		}
+		a.lits = append(a.lits, best)
+		j := 0
+		for _, m := range a.pairs {
+			if m.lit == best && (j == 0 || a.matched[j-1] != m.id) {
+				a.matched[j] = m.id
+				j++
+			}
+		}
+		a.matched = a.matched[:j]
+	}
+	return reduction(len(a.lits), len(a.matched)) > 0
+}
+
+// matchClauses collects in pairs the literals l outside of the pattern, with
+// the clauses c ∨ seed of matched, such that c ∨ l is a clause of the formula,
+// and counts the clauses matched by each literal in counts. The pairs of a
+// clause are contiguous.
+func (a *adder) matchClauses(seed Lit) {
+	a.pairs = a.pairs[:0]
+	for _, id := range a.matched {
+		c := a.clauses[id]
+		least := Lit(0)
+		for _, l := range c {
+			if l != seed && (least == 0 || len(a.occurs[l.index()]) < len(a.occurs[least.index()])) {
+				least = l
+			}
+		}
+		if least == 0 {
+			continue
+		}
+		for _, l := range c {
+			if l != seed {
+				a.marks[l.index()] = true
+			}
+		}
+		start := len(a.pairs)
+		for _, d := range a.live(least) {
+			other := a.clauses[d]
+			if len(other) != len(c) || d == id {
+				continue
+			}
+			a.budget -= len(other)
+			extra := Lit(0)
+			for _, l := range other {
+				if !a.marks[l.index()] {
+					if extra != 0 {
+						extra = 0
+						break
+					}
+					extra = l
+				}
+			}
+			if extra != 0 && !a.inPattern(extra) && !a.duplicate(start, extra) {
+				a.pairs = append(a.pairs, match{extra, id})
+				a.counts[extra.index()]++
+			}
+		}
+		for _, l := range c {
+			if l != seed {
+				a.marks[l.index()] = false
+			}
+		}
+	}
+}
+
+// inPattern reports whether the literal belongs to the pattern.
+func (a *adder) inPattern(lit Lit) bool {
+	for _, l := range a.lits {
+		if l == lit {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicate reports whether the literal is already paired with the clause
+// whose pairs start at the given index.
+func (a *adder) duplicate(start int, lit Lit) bool {
+	for _, m := range a.pairs[start:] {
+		if m.lit == lit {
+			return true
+		}
+	}
+	return false
+}
+
+// replace replaces the clauses of the pattern using a fresh variable x. The
+// clauses x ∨ l are added to the proof first, being blocked on x, followed by
+// the clauses c ∨ ¬x, whose resolvents on ¬x are the replaced clauses.
+func (a *adder) replace() {
+	solver := a.solver
+	seed := a.lits[0]
+	x := Lit(solver.newVar(true))
+	solver.stats.NumAdded++
+	solver.stats.NumAddSaved += reduction(len(a.lits), len(a.matched))
+	a.occurs = append(a.occurs, nil, nil)
+	a.marks = append(a.marks, false, false)
+	a.touched = append(a.touched, false)
+	a.counts = append(a.counts, 0, 0)
+	var removed []int
+	for _, id := range a.matched {
+		c := a.clauses[id]
+		for _, l := range a.lits[1:] {
+			lits := append([]Lit(nil), c...)
+			for i, k := range lits {
+				if k == seed {
+					lits[i] = l
+				}
+			}
+			removed = append(removed, a.findClause(lits))
+		}
+		removed = append(removed, id)
+	}
+	added := make([][]Lit, 0, len(a.lits)+len(a.matched))
+	for _, l := range a.lits {
+		added = append(added, []Lit{x, l})
+	}
+	for _, id := range a.matched {
+		lits := []Lit{x.negation()}
+		for _, l := range a.clauses[id] {
+			if l != seed {
+				lits = append(lits, l)
+			}
+		}
+		added = append(added, lits)
+	}
+	for _, lits := range added {
+		solver.proofAdd(lits)
+	}
+	for _, id := range removed {
+		// Duplicate clauses of the seed may give the same clause twice
+		if id >= 0 && a.clauses[id] != nil {
+			solver.proofDelete(a.clauses[id])
+			a.remove(id)
+		}
+	}
+	for _, lits := range added {
+		a.add(lits)
+	}
+}
@@ -0,0 +1,99 @@
+package egosat
+
+import "testing"
+
+func TestNewVar(t *testing.T) {
+	solver := CreateSolver(1, 2)
+	solver.AddClause([]Lit{1, 2})
+	v := solver.NewVar()
+	if v != 3 || solver.NumVariables() != 3 {
+		t.FailNow()
+	}
+	if err := solver.AddClause([]Lit{-1, -3}); err != nil {
+		t.FailNow()
+	}
+	if solver.AddClause([]Lit{4}) != ErrInvalidLiteral {
+		t.Fail()
+	}
+}
+
+func TestAddVariables(t *testing.T) {
+	// A pairwise at-most-one constraint over 6 literals
+	solver := CreateSolver(15, 6)
+	for i := 1; i <= 6; i++ {
+		for j := i + 1; j <= 6; j++ {
+			solver.AddClause([]Lit{Lit(-i), Lit(-j)})
+		}
+	}
+	if !solver.addVariables() || solver.stats.NumAdded == 0 || solver.NumVariables() == 6 {
+		t.FailNow()
+	}
+	if solver.NumClauses() >= 15 || !solver.hidden[7] {
+		t.Fail()
+	}
+	// The branchers are created once for all fresh variables
+	if h := solver.brancher.(*vsids); len(h.activity) != 2*solver.NumVariables() {
+		t.Fail()
+	}
+}
+
+func TestAddVariablesModel(t *testing.T) {
+	r := createRandom(7)
+	for n := 0; n < 20; n++ {
+		var clauses [][]Lit
+		solver := CreateSolver(200, 40)
+		add := func(clause []Lit) {
+			clauses = append(clauses, clause)
+			solver.AddClause(append([]Lit(nil), clause...))
+		}
+		for g := 0; g < 4; g++ {
+			group := make([]Lit, 5+r.intn(4))
+			for i := range group {
+				group[i] = Lit(r.intn(40) + 1)
+			}
+			add(append([]Lit(nil), group...))
+			for i := range group {
+				for j := i + 1; j < len(group); j++ {
+					add([]Lit{group[i].negation(), group[j].negation()})
+				}
+			}
+		}
+		for i := 0; i < 60; i++ {
+			clause := make([]Lit, 3)
+			for j := range clause {
+				clause[j] = Lit(r.intn(40) + 1)
+				if r.float() < 0.5 {
+					clause[j] = clause[j].negation()
+				}
+			}
+			add(clause)
+		}
+		params := SolverParams{
+			MaxConflict:         1000,
+			MaxLearnts:          100,
+			VarActivityDecay:    0.95,
+			ClauseActivityDecay: 0.999,
+			AddVariables:        true,
+		}
+		res := solver.Search(params)
+		for res == LNULL {
+			res = solver.Search(params)
+		}
+		if res != LTRUE {
+			continue
+		}
+		model := solver.Model()
+		if len(model) != 40 {
+			t.Fatalf("model has %d variables", len(model))
+		}
+		for _, c := range clauses {
+			satisfied := false
+			for _, l := range c {
+				satisfied = satisfied || model[l.variable()-1] == l
+			}
+			if !satisfied {
+				t.Fatalf("model falsifies %v", c)
+			}
+		}
+	}
+}
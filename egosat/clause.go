@@ -1,78 +1,178 @@
 package egosat
 
-// The Clause struct is used for storing CNF clauses.
-type Clause struct {
-	learnt   bool    // Indicates whether clause was learnt or not
-	activity float64 // Gives the activity of the clause
-	lits     []Lit   //
+// A Clause refers to a CNF clause stored in the clause arena of a solver, by
+// the offset of its header. The zero Clause refers to no clause.
+type Clause uint32
+
+// noClause is the zero Clause, which refers to no clause.
+const noClause = Clause(0)
+
+// The watcher struct is an entry of a watch list. If the blocking literal is
+// true the clause is satisfied, and it can be skipped without being accessed.
+type watcher struct {
+	clause  Clause // Clause watching the literal
+	blocker Lit    // Literal of the clause other than the watched one
+}
+
+// The binary struct is an entry of the implication list of a literal,
+// which holds the other literal of a binary clause containing its negation.
+type binary struct {
+	lit    Lit  // Literal implied through the binary clause
+	learnt bool // Whether the binary clause was learnt
 }
 
-// simplify simplifies returns true if the invoking clause is trivially
-// satisfiable, and otherwise will eliminate any false literals from the clause
-// before returning false.
-func (clause *Clause) simplify(solver *Solver) bool {
-	var j, val Lbool
-	for _, l := range clause.lits {
-		val = solver.litValue(l)
-		if val == LTRUE {
-			return true
+// The reason struct records why a literal was assigned. Binary clauses are
+// not stored in the clause arena, so a literal implied by a binary clause
+// refers to the other literal of that clause instead.
+type reason struct {
+	clause Clause // Clause which implied the literal, noClause otherwise
+	other  Lit    // False literal of the binary clause which implied the literal
+}
+
+// simplify removes the false literals of the clause, which must be at decision
+// level 0. A satisfied clause is deleted, and a clause left with at most two
+// literals leaves the arena to become a binary clause or a unit, which is
+// enqueued but not propagated. The first result reports whether the clause was
+// removed from the arena, and the second whether the clause is not empty and
+// its unit is not false.
+func (clause Clause) simplify(solver *Solver) (removed bool, ok bool) {
+	a := &solver.arena
+	n := a.size(clause)
+	for i := 0; i < n; i++ {
+		if solver.litValue(a.lit(clause, i)) == LTRUE {
+			solver.deleteClause(clause)
+			return true, true
 		}
-		if val == LNULL {
-			clause.lits[j] = l
+	}
+	j := 0
+	for i := 0; i < n; i++ {
+		if solver.litValue(a.lit(clause, i)) == LNULL {
+			j++
+		}
+	}
+	if j == n && n > 2 {
+		return false, true
+	}
+	if j < n && j > 0 && solver.proof != nil {
+		solver.proofLits = solver.proofLits[:0]
+		for i := 0; i < n; i++ {
+			if l := a.lit(clause, i); solver.litValue(l) == LNULL {
+				solver.proofLits = append(solver.proofLits, l)
+			}
+		}
+		solver.proofAdd(solver.proofLits)
+		solver.proofDeleteClause(clause)
+	}
+	w0, w1 := a.lit(clause, 0), a.lit(clause, 1)
+	j = 0
+	for i := 0; i < n; i++ {
+		if l := a.lit(clause, i); solver.litValue(l) == LNULL {
+			a.setLit(clause, j, l)
 			j++
 		}
 	}
-	clause.lits = clause.lits[0:j]
-	return false
+	a.shrink(clause, j)
+	return clause.detach(solver, w0, w1)
+}
+
+// detach moves a clause of the arena shortened to at most two literals out of
+// the arena, as a binary clause or an enqueued unit. Otherwise the clause
+// watches those of its first two literals which were not watched before
+// shortening, w0 and w1, while the watchers of the removed literals are left
+// to be dropped lazily. The results are those of simplify.
+func (clause Clause) detach(solver *Solver, w0, w1 Lit) (removed bool, ok bool) {
+	a := &solver.arena
+	switch a.size(clause) {
+	case 0:
+		a.free(clause)
+		return true, false
+	case 1:
+		l := a.lit(clause, 0)
+		a.free(clause)
+		return true, solver.enqueue(l, noClause)
+	case 2:
+		solver.addBinary(a.lit(clause, 0), a.lit(clause, 1), a.learnt(clause))
+		a.free(clause)
+		return true, true
+	}
+	for i := 0; i < 2; i++ {
+		if l := a.lit(clause, i); l != w0 && l != w1 {
+			solver.addWatcher(l.negation(), clause, a.lit(clause, 1-i))
+		}
+	}
+	return false, true
 }
 
 // propagate will enqueue unit information if the clause has become unit. It is
-// assumed (and enforced at the beginning of the function) that lit is the first
-// literal in the invoking clause. Another precondition is that lit is not being
-// watched by the invoking clause. If any literals in the clause beyond the
-// first two literals are not yet falsified, the clause will be set to watch one
-// of those.
-func (clause *Clause) propagate(solver *Solver, lit Lit) bool {
-	if clause.lits[0] == lit.negation() {
-		clause.lits[0], clause.lits[1] = clause.lits[1], clause.lits[0]
+// assumed that lit has just become true, and its negation is moved to the
+// second position if it is one of the two watched literals. Otherwise, or if
+// the clause was deleted, the watcher is stale and is dropped. If any literals
+// in the clause beyond the first two literals are not yet falsified, the clause
+// will be set to watch one of those. Otherwise the first literal is implied at
+// the highest level of the other literals, which is below the current level if
+// the falsified literals were assigned out of order after chronological
+// backtracking. The first result reports whether the clause still watches the
+// negation of lit, in which case the caller keeps it in the watch list being
+// traversed, and the second whether the clause is not conflicting.
+func (clause Clause) propagate(solver *Solver, lit Lit) (keep bool, ok bool) {
+	a := &solver.arena
+	if a.lit(clause, 0) == lit.negation() {
+		a.swap(clause, 0, 1)
 	}
-	if solver.litValue(clause.lits[0]) == LTRUE {
-		solver.addWatcher(lit, clause)
-		return true
+	if a.deleted(clause) || a.lit(clause, 1) != lit.negation() {
+		return false, true
 	}
-	for i := 2; i < len(clause.lits); i++ {
-		if solver.litValue(clause.lits[i]) != LFALSE {
-			clause.lits[1], clause.lits[i] = clause.lits[i], clause.lits[1]
-			solver.addWatcher(clause.lits[1].negation(), clause)
-			return true
+	first := a.lit(clause, 0)
+	if solver.litValue(first) == LTRUE {
+		return true, true
+	}
+	n := a.size(clause)
+	for i := 2; i < n; i++ {
+		if l := a.lit(clause, i); solver.litValue(l) != LFALSE {
+			a.swap(clause, 1, i)
+			solver.addWatcher(l.negation(), clause, first)
+			return false, true
+		}
+	}
+	keep = true
+	level := solver.level[a.lit(clause, 1).variable()]
+	if level < solver.DecisionLevel() {
+		for i := 2; i < n; i++ {
+			if solver.level[a.lit(clause, i).variable()] > level {
+				level = solver.level[a.lit(clause, i).variable()]
+				a.swap(clause, 1, i)
+				keep = false
+			}
 		}
 	}
-	solver.addWatcher(lit, clause)
-	return solver.enqueue(clause.lits[0], clause)
+	if !keep {
+		solver.addWatcher(a.lit(clause, 1).negation(), clause, first)
+	}
+	return keep, solver.enqueueAt(first, clause, level)
 }
 
 // calcReason will compute the assignments that force the clause to be
-// conflicting. As a precondition, lit will either be LNULL (0) or the first
-// literal in the invoking clause. If p is LNULL, this method will return the
-// negation of every literal in the clause, otherwise this method will return
-// the negation of every literal except the first literal.
-func (clause *Clause) calcReason(lit Lit) (reason []Lit) {
+// conflicting, and append them to the given slice. As a precondition, lit will
+// either be LNULL (0) or the first literal in the invoking clause. If p is
+// LNULL, this method will return the negation of every literal in the clause,
+// otherwise this method will return the negation of every literal except the
+// first literal.
+func (clause Clause) calcReason(solver *Solver, lit Lit, reason []Lit) []Lit {
 	var i int
 	if lit == Lit(0) {
 		i = 0
 	} else {
 		i = 1
 	}
-	for ; i < len(clause.lits); i++ {
-		reason = append(reason, clause.lits[i].negation())
+	for ; i < solver.arena.size(clause); i++ {
+		reason = append(reason, solver.arena.lit(clause, i).negation())
 	}
-	return
+	return reason
 }
 
-// removeWatched will remove the clause from the watcher lists of its first two
-// literals.
-func (clause *Clause) removeWatched(solver *Solver) {
-	for i := 0; i < 2; i++ {
-		solver.removeWatcher(clause.lits[i], clause)
-	}
+// watches reports whether the clause, which must not be deleted, still watches
+// the negation of the literal, i.e. whether its watcher in the watch list of
+// the literal is not stale.
+func (clause Clause) watches(solver *Solver, lit Lit) bool {
+	return solver.arena.lit(clause, 0) == lit.negation() || solver.arena.lit(clause, 1) == lit.negation()
 }
@@ -4,17 +4,27 @@ import "testing"
 
 // TestSimplify tests the behavior of the Clause.simplify method.
 func TestSimplify(t *testing.T) {
-	// Test that clauses with true literals will result in true being returned
-	solver := Solver{assignments: []Lbool{LNULL, LFALSE}}
-	clause := Clause{learnt: false, activity: 0.0, lits: []Lit{-1}}
-	if !clause.simplify(&solver) {
+	solver := CreateSolver(4, 5)
+	for _, c := range [][]Lit{{1, 3, 4}, {-1, 3, 4}, {-1, 3, 4, 5}, {-1, -2, 3}} {
+		solver.AddClause(c)
+	}
+	solver.enqueue(1, noClause)
+	solver.enqueue(2, noClause)
+	c := solver.clauses
+	// Test that satisfied clauses are deleted
+	if removed, ok := c[0].simplify(solver); !removed || !ok || !solver.arena.deleted(c[0]) {
+		t.Fail()
+	}
+	// Test that clauses left with two literals become binary clauses
+	if removed, ok := c[1].simplify(solver); !removed || !ok || len(solver.binaries[Lit(-3).index()]) != 1 {
+		t.Fail()
+	}
+	// Test that false literals are removed from longer clauses
+	if removed, ok := c[2].simplify(solver); removed || !ok || solver.arena.size(c[2]) != 3 {
 		t.Fail()
 	}
-	// Test that false literals will be removed from clauses
-	solver = Solver{assignments: []Lbool{LNULL, LTRUE, LNULL}}
-	clause = Clause{learnt: false, activity: 0.0, lits: []Lit{-1, 2}}
-	clause.simplify(&solver)
-	if clause.lits[0] != Lit(2) || len(clause.lits) != 1 {
+	// Test that clauses left with one literal become units
+	if removed, ok := c[3].simplify(solver); !removed || !ok || solver.varValue(3) != LTRUE {
 		t.Fail()
 	}
 }
@@ -24,27 +34,32 @@ func TestSimplify(t *testing.T) {
 // assignments.
 func TestClausePropagate(t *testing.T) {
 	solver := Solver{
-		clauses: []*Clause{
-			{lits: []Lit{-1, 2, 3}},
-			{lits: []Lit{1, -2, 3}},
-			{lits: []Lit{1, 2, -3}},
-		},
+		arena:       createArena(0),
 		assignments: []Lbool{LNULL, LTRUE, LNULL, LNULL},
 	}
-	solver.watcherLists = [][]*Clause{
-		{solver.clauses[0]},                    // watch lists for 1
-		{solver.clauses[1], solver.clauses[2]}, // watch lists for -1
-		{solver.clauses[1]},
-		{solver.clauses[0], solver.clauses[2]},
+	for _, lits := range [][]Lit{{-1, 2, 3}, {1, -2, 3}, {1, 2, -3}} {
+		solver.clauses = append(solver.clauses, solver.arena.alloc(lits, false))
+	}
+	solver.watcherLists = [][]watcher{
+		{{solver.clauses[0], 2}},                         // watch lists for 1
+		{{solver.clauses[1], 1}, {solver.clauses[2], 1}}, // watch lists for -1
+		{{solver.clauses[1], -2}},
+		{{solver.clauses[0], -1}, {solver.clauses[2], 2}},
 		{},
 		{},
 	}
 	// The first clause should be added to the watcher list of -3
-	solver.clauses[0].propagate(&solver, Lit(1))
+	keep, ok := solver.clauses[0].propagate(&solver, Lit(1))
+	if keep || !ok {
+		t.Fail()
+	}
 	if len(solver.watcherLists[Lit(-3).index()]) != 1 {
 		t.Fail()
 	}
-	if solver.watcherLists[Lit(-3).index()][0] != solver.clauses[0] {
+	if solver.watcherLists[Lit(-3).index()][0].clause != solver.clauses[0] {
+		t.Fail()
+	}
+	if solver.watcherLists[Lit(-3).index()][0].blocker != Lit(2) {
 		t.Fail()
 	}
 }
@@ -54,15 +69,16 @@ func TestClausePropagate(t *testing.T) {
 // If the lit argument of calcreason is not null then it is assumed to be the
 // first literal of the clause and is not returned.
 func TestCalcReason(t *testing.T) {
-	c := &Clause{lits: []Lit{1, 2, 3}}
-	ret := c.calcReason(Lit(1))
+	solver := &Solver{arena: createArena(0)}
+	c := solver.arena.alloc([]Lit{1, 2, 3}, false)
+	ret := c.calcReason(solver, Lit(1), nil)
 	if len(ret) != 2 {
 		t.Fail()
 	}
 	if ret[0] != Lit(-2) || ret[1] != Lit(-3) {
 		t.Fail()
 	}
-	ret = c.calcReason(Lit(0))
+	ret = c.calcReason(solver, Lit(0), nil)
 	if len(ret) != 3 {
 		t.Fail()
 	}
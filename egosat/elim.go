@@ -0,0 +1,566 @@
+package egosat
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// These constants bound the work of bounded variable elimination.
+const (
+	elimOccLimit    = 32      // Occurrences above which a variable with both polarities is kept
+	elimClauseLimit = 16      // Resolvents longer than this prevent an elimination
+	elimXorLimit    = 4       // Longest clauses considered as part of a XOR gate
+	elimRounds      = 3       // Rounds over the variables touched by eliminations
+	elimBudget      = 1 << 24 // Literals visited by resolution before preprocessing gives up
+)
+
+// The eliminator struct holds the occurrence lists used by bounded variable
+// elimination. The irredundant clauses are copied out of the solver, and
+// removed clauses are set to nil rather than taken out of the occurrence lists.
+type eliminator struct {
+	solver     *Solver
+	clauses    [][]Lit // Irredundant clauses which are not satisfied at level 0
+	occurs     [][]int // Clauses containing each literal, indexed by Lit.index
+	marks      []bool  // Marks literals of the clause being examined, indexed by Lit.index
+	touched    []bool  // Marks variables whose occurrences changed in the current round
+	gate       []int   // Clauses of the definition found for the variable being eliminated
+	resolvents [][]Lit // Resolvents of the variable being eliminated
+	buf        []Lit   // Scratch space for resolvents and XOR clauses
+	budget     int     // Literals which may still be visited by resolution
+	unsat      bool    // Set when the empty clause has been derived
+}
+
+// eliminate runs bounded variable elimination at decision level 0. A variable
+// is eliminated by replacing the clauses containing it with their resolvents,
+// provided this does not increase the number of clauses. If the clauses define
+// the variable as an AND, ITE or XOR gate, only resolvents between gate and
+// non-gate clauses are needed. The removed clauses are kept for extending the
+// model. Resolution stops once the budget of literals visited is spent. It
+// returns false if the formula was found unsatisfiable.
+func (solver *Solver) eliminate(budget int) bool {
+	if solver.propagate() != noClause {
+		return false
+	}
+	if !solver.simplifyAll() {
+		return false
+	}
+	e := solver.createEliminator()
+	e.budget = budget
+	var queue []int
+	for v := 1; v <= solver.NumVariables(); v++ {
+		if solver.varValue(v) == LNULL && !solver.eliminated[v] {
+			queue = append(queue, v)
+		}
+	}
+	for round := 0; round < elimRounds && len(queue) > 0 && !e.unsat; round++ {
+		cost := make([]int, solver.NumVariables()+1)
+		for _, v := range queue {
+			cost[v] = len(e.live(Lit(v))) * len(e.live(Lit(-v)))
+		}
+		sort.SliceStable(queue, func(i, j int) bool { return cost[queue[i]] < cost[queue[j]] })
+		for v := range e.touched {
+			e.touched[v] = false
+		}
+		for _, v := range queue {
+			if e.budget <= 0 || e.unsat || !solver.checkInterrupt() {
+				break
+			}
+			e.eliminate(v)
+		}
+		queue = queue[:0]
+		for v, touched := range e.touched {
+			if touched && !solver.eliminated[v] && solver.varValue(v) == LNULL {
+				queue = append(queue, v)
+			}
+		}
+	}
+	if e.unsat {
+		return false
+	}
+	return solver.rebuild(e.clauses)
+}
+
+// createEliminator copies the irredundant clauses of the solver into the
+// occurrence lists of a new eliminator.
+func (solver *Solver) createEliminator() *eliminator {
+	nVars := solver.NumVariables()
+	e := &eliminator{
+		solver:  solver,
+		occurs:  make([][]int, 2*nVars),
+		marks:   make([]bool, 2*nVars),
+		touched: make([]bool, nVars+1),
+		budget:  elimBudget,
+	}
+	for _, lits := range solver.irredundant() {
+		e.add(lits)
+	}
+	return e
+}
+
+// irredundant returns copies of the original clauses, including the original
+// binary clauses but not the learnt ones.
+func (solver *Solver) irredundant() [][]Lit {
+	var clauses [][]Lit
+	for _, c := range solver.clauses {
+		clauses = append(clauses, solver.arena.appendLits(nil, c))
+	}
+	for v := 1; v <= solver.NumVariables(); v++ {
+		for _, l := range []Lit{Lit(v), Lit(-v)} {
+			for _, b := range solver.binaries[l.index()] {
+				// Binary clauses with a duplicate literal are included too
+				if !b.learnt && l.negation().index() <= b.lit.index() {
+					clauses = append(clauses, []Lit{l.negation(), b.lit})
+				}
+			}
+		}
+	}
+	return clauses
+}
+
+// add adds a clause to the occurrence lists, after removing false and
+// duplicate literals. Satisfied and tautological clauses are dropped.
+func (e *eliminator) add(lits []Lit) {
+	j := 0
+	taut := false
+	for _, l := range lits {
+		switch {
+		case e.solver.litValue(l) == LTRUE || e.marks[l.negation().index()]:
+			taut = true
+		case e.solver.litValue(l) == LFALSE || e.marks[l.index()]:
+		default:
+			e.marks[l.index()] = true
+			lits[j] = l
+			j++
+		}
+	}
+	lits = lits[:j]
+	for _, l := range lits {
+		e.marks[l.index()] = false
+	}
+	if taut {
+		return
+	}
+	if len(lits) == 0 {
+		e.unsat = true
+		return
+	}
+	id := len(e.clauses)
+	e.clauses = append(e.clauses, lits)
+	for _, l := range lits {
+		e.occurs[l.index()] = append(e.occurs[l.index()], id)
+		e.touched[l.variable()] = true
+	}
+}
+
+// remove removes a clause from the eliminator.
+func (e *eliminator) remove(id int) {
+	for _, l := range e.clauses[id] {
+		e.touched[l.variable()] = true
+	}
+	e.clauses[id] = nil
+}
+
+// live returns the clauses containing the literal, after dropping removed
+// clauses from its occurrence list.
+func (e *eliminator) live(l Lit) []int {
+	ids := e.occurs[l.index()]
+	j := 0
+	for _, id := range ids {
+		if e.clauses[id] != nil {
+			ids[j] = id
+			j++
+		}
+	}
+	e.occurs[l.index()] = ids[:j]
+	return ids[:j]
+}
+
+// eliminate eliminates the variable if the number of non-tautological
+// resolvents does not exceed the number of clauses containing it, and none of
+// them is too long.
+func (e *eliminator) eliminate(v int) {
+	pos, neg := e.live(Lit(v)), e.live(Lit(-v))
+	if len(pos) > 0 && len(neg) > 0 && len(pos)+len(neg) > elimOccLimit {
+		return
+	}
+	gate := e.findGate(v)
+	e.resolvents = e.resolvents[:0]
+	for _, p := range pos {
+		for _, n := range neg {
+			if gate && e.inGate(p) == e.inGate(n) {
+				continue
+			}
+			r, ok := e.resolve(e.clauses[p], e.clauses[n], v)
+			if !ok {
+				continue
+			}
+			if len(r) > elimClauseLimit || len(e.resolvents) == len(pos)+len(neg) {
+				return
+			}
+			e.resolvents = append(e.resolvents, append([]Lit(nil), r...))
+		}
+	}
+	if gate {
+		e.solver.stats.NumGates++
+	}
+	for _, r := range e.resolvents {
+		e.solver.proofAdd(r)
+	}
+	for _, ids := range [][]int{pos, neg} {
+		for _, id := range ids {
+			e.solver.proofDelete(e.clauses[id])
+			e.solver.extension = append(e.solver.extension, witnessFirst(e.clauses[id], v))
+			e.remove(id)
+		}
+	}
+	for _, r := range e.resolvents {
+		e.add(r)
+	}
+	e.solver.eliminated[v] = true
+	e.solver.numEliminated++
+	e.solver.stats.NumEliminated++
+}
+
+// resolve returns the resolvent of two clauses on the variable in scratch
+// space, and false if the resolvent is a tautology.
+func (e *eliminator) resolve(a, b []Lit, v int) ([]Lit, bool) {
+	e.budget -= len(a) + len(b)
+	r := e.buf[:0]
+	for _, l := range a {
+		if l.variable() != v {
+			e.marks[l.index()] = true
+			r = append(r, l)
+		}
+	}
+	ok := true
+	for _, l := range b {
+		if l.variable() == v || e.marks[l.index()] {
+			continue
+		}
+		if e.marks[l.negation().index()] {
+			ok = false
+			break
+		}
+		r = append(r, l)
+	}
+	for _, l := range a {
+		e.marks[l.index()] = false
+	}
+	e.buf = r
+	return r, ok
+}
+
+// witnessFirst returns a copy of the clause with the literal of the variable in
+// first position, which is the literal flipped when extending the model.
+func witnessFirst(lits []Lit, v int) []Lit {
+	c := append([]Lit(nil), lits...)
+	for i, l := range c {
+		if l.variable() == v {
+			c[0], c[i] = c[i], c[0]
+		}
+	}
+	return c
+}
+
+// findGate looks for clauses defining the variable as an AND, ITE or XOR of
+// other literals, and stores them in e.gate. Resolvents of two gate clauses are
+// tautologies, and resolvents of two non-gate clauses are implied by the
+// others, so only resolvents between gate and non-gate clauses are needed.
+func (e *eliminator) findGate(v int) bool {
+	e.gate = e.gate[:0]
+	if e.findAnd(Lit(v)) || e.findAnd(Lit(-v)) || e.findIte(Lit(v)) || e.findXor(v) {
+		return true
+	}
+	e.gate = e.gate[:0]
+	return false
+}
+
+// inGate reports whether the clause is part of the gate found by findGate.
+func (e *eliminator) inGate(id int) bool {
+	for _, g := range e.gate {
+		if g == id {
+			return true
+		}
+	}
+	return false
+}
+
+// findAnd looks for a definition of the literal as the conjunction of literals
+// x1..xk, given by the binary clauses (¬l ∨ xi) and the clause (l ∨ ¬x1 ∨ ...
+// ∨ ¬xk). Equivalences are found as conjunctions of one literal.
+func (e *eliminator) findAnd(l Lit) bool {
+	bins := e.live(l.negation())
+	for _, id := range bins {
+		if c := e.clauses[id]; len(c) == 2 {
+			e.marks[other(c, l.negation()).index()] = true
+		}
+	}
+	long := -1
+	for _, id := range e.live(l) {
+		all := true
+		for _, y := range e.clauses[id] {
+			if y != l && !e.marks[y.negation().index()] {
+				all = false
+				break
+			}
+		}
+		if all {
+			long = id
+			break
+		}
+	}
+	for _, id := range bins {
+		if c := e.clauses[id]; len(c) == 2 {
+			e.marks[other(c, l.negation()).index()] = false
+		}
+	}
+	if long < 0 {
+		return false
+	}
+	e.gate = append(e.gate, long)
+	for _, y := range e.clauses[long] {
+		e.marks[y.index()] = true
+	}
+	for _, id := range bins {
+		if c := e.clauses[id]; len(c) == 2 && e.marks[other(c, l.negation()).negation().index()] {
+			e.gate = append(e.gate, id)
+		}
+	}
+	for _, y := range e.clauses[long] {
+		e.marks[y.index()] = false
+	}
+	return true
+}
+
+// findIte looks for a definition of the literal as if c then t else e, given by
+// the clauses (¬l ∨ ¬c ∨ t), (¬l ∨ c ∨ e), (l ∨ ¬c ∨ ¬t) and (l ∨ c ∨ ¬e).
+// The definition of the negation is the same with t and e negated.
+func (e *eliminator) findIte(l Lit) bool {
+	neg := e.live(l.negation())
+	for i, a := range neg {
+		if len(e.clauses[a]) != 3 {
+			continue
+		}
+		for _, b := range neg[i+1:] {
+			if len(e.clauses[b]) != 3 {
+				continue
+			}
+			p, q := others(e.clauses[a], l.negation())
+			r, s := others(e.clauses[b], l.negation())
+			for k := 0; k < 4; k++ {
+				if r == p.negation() {
+					c1 := e.findClause([]Lit{l, p, q.negation()})
+					c2 := e.findClause([]Lit{l, r, s.negation()})
+					if c1 >= 0 && c2 >= 0 {
+						e.gate = append(e.gate, a, b, c1, c2)
+						return true
+					}
+				}
+				p, q = q, p
+				if k == 1 {
+					r, s = s, r
+				}
+			}
+		}
+	}
+	return false
+}
+
+// findXor looks for a definition of the variable as the exclusive or of at
+// most elimXorLimit-1 other variables, given by every clause over these
+// variables whose number of negative literals has the same parity.
+func (e *eliminator) findXor(v int) bool {
+	for _, id := range e.live(Lit(v)) {
+		c := e.clauses[id]
+		if len(c) < 3 || len(c) > elimXorLimit {
+			continue
+		}
+		e.gate = e.gate[:0]
+		for mask := 0; mask < 1<<len(c); mask++ {
+			if bits.OnesCount(uint(mask))%2 != 0 {
+				continue
+			}
+			lits := append(e.buf[:0], c...)
+			for i := range lits {
+				if mask>>i&1 == 1 {
+					lits[i] = lits[i].negation()
+				}
+			}
+			e.buf = lits
+			found := e.findClause(lits)
+			if found < 0 {
+				break
+			}
+			e.gate = append(e.gate, found)
+		}
+		if len(e.gate) == 1<<(len(c)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// findClause returns a clause consisting of exactly the given literals, or -1
+// if there is none.
+func (e *eliminator) findClause(lits []Lit) int {
+	for _, l := range lits {
+		e.marks[l.index()] = true
+	}
+	found := -1
+	for _, id := range e.live(lits[0]) {
+		c := e.clauses[id]
+		if len(c) != len(lits) {
+			continue
+		}
+		all := true
+		for _, l := range c {
+			if !e.marks[l.index()] {
+				all = false
+				break
+			}
+		}
+		if all {
+			found = id
+			break
+		}
+	}
+	for _, l := range lits {
+		e.marks[l.index()] = false
+	}
+	return found
+}
+
+// other returns the literal of the binary clause which is not l.
+func other(c []Lit, l Lit) Lit {
+	if c[0] == l {
+		return c[1]
+	}
+	return c[0]
+}
+
+// others returns the literals of the ternary clause which are not l.
+func others(c []Lit, l Lit) (Lit, Lit) {
+	switch l {
+	case c[0]:
+		return c[1], c[2]
+	case c[1]:
+		return c[0], c[2]
+	}
+	return c[0], c[1]
+}
+
+// rebuild replaces the irredundant clauses of the solver with the given ones
+// and drops the learnt clauses, binary or not, containing eliminated
+// variables. Eliminated variables are left unassigned and never branched on,
+// and their values are set when the model is extended. It returns false if the
+// formula was found unsatisfiable.
+func (solver *Solver) rebuild(clauses [][]Lit) bool {
+	for _, c := range solver.clauses {
+		solver.arena.free(c)
+	}
+	solver.clauses = solver.clauses[:0]
+	for i := range solver.watcherLists {
+		solver.watcherLists[i] = solver.watcherLists[i][:0]
+		solver.keepLearntBinaries(indexLit(i))
+	}
+	solver.numBinaries = 0
+	j := 0
+	for _, c := range solver.learntClauses {
+		if solver.containsEliminated(c) {
+			solver.proofDeleteClause(c)
+			solver.arena.free(c)
+			continue
+		}
+		solver.addWatcher(solver.arena.lit(c, 0).negation(), c, solver.arena.lit(c, 1))
+		solver.addWatcher(solver.arena.lit(c, 1).negation(), c, solver.arena.lit(c, 0))
+		solver.learntClauses[j] = c
+		j++
+	}
+	solver.learntClauses = solver.learntClauses[:j]
+	for _, l := range solver.trail {
+		solver.reasons[l.variable()] = reason{}
+	}
+	for _, lits := range clauses {
+		if lits != nil {
+			if ok, _ := solver.attach(lits, false); !ok {
+				return false
+			}
+		}
+	}
+	solver.collectGarbage()
+	return solver.propagate() == noClause
+}
+
+// keepLearntBinaries removes the irredundant binary clauses from the
+// implication list of the literal, and the learnt ones containing an
+// eliminated variable, whose deletion is logged to the proof once.
+func (solver *Solver) keepLearntBinaries(l Lit) {
+	bs := solver.binaries[l.index()]
+	j := 0
+	for _, b := range bs {
+		switch {
+		case !b.learnt:
+		case solver.eliminated[l.variable()] || solver.eliminated[b.lit.variable()]:
+			if l.negation().index() <= b.lit.index() {
+				solver.proofDelete(append(solver.proofLits[:0], l.negation(), b.lit))
+			}
+		default:
+			bs[j] = b
+			j++
+		}
+	}
+	solver.binaries[l.index()] = bs[:j]
+}
+
+// containsEliminated reports whether the clause contains an eliminated variable.
+func (solver *Solver) containsEliminated(c Clause) bool {
+	for i := 0; i < solver.arena.size(c); i++ {
+		if solver.eliminated[solver.arena.lit(c, i).variable()] {
+			return true
+		}
+	}
+	return false
+}
+
+// extendModel builds the model from the current assignment, extended to the
+// variables removed by elimination, and leaves out the hidden variables.
+func (solver *Solver) extendModel() {
+	solver.model = make([]Lit, 0, solver.NumVariables())
+	for v := 1; v <= solver.NumVariables(); v++ {
+		if solver.assignments[v] == LTRUE {
+			solver.model = append(solver.model, Lit(v))
+		} else {
+			solver.model = append(solver.model, Lit(-v))
+		}
+	}
+	ExtendModel(solver.model, solver.extension)
+	j := 0
+	for _, l := range solver.model {
+		if !solver.hidden[l.variable()] {
+			solver.model[j] = l
+			j++
+		}
+	}
+	solver.model = solver.model[:j]
+}
+
+// ExtendModel repairs a model, given as the true literal of every variable in
+// increasing order of variables, so that it satisfies the clauses removed by
+// simplification, as given by Extension. The clauses are traversed in reverse
+// order, and the first literal of every falsified clause is flipped to true,
+// which satisfies all of them.
+func ExtendModel(model []Lit, extension [][]Lit) {
+	for i := len(extension) - 1; i >= 0; i-- {
+		c := extension[i]
+		satisfied := false
+		for _, l := range c {
+			if model[l.variable()-1] == l {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			model[c[0].variable()-1] = c[0]
+		}
+	}
+}
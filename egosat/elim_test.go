@@ -0,0 +1,137 @@
+package egosat
+
+import "testing"
+
+func TestFindGate(t *testing.T) {
+	gates := []struct {
+		clauses [][]Lit
+		size    int
+	}{
+		{[][]Lit{{-4, 1}, {-4, 2}, {4, -1, -2}, {4, 3}}, 3},            // 4 = 1 AND 2
+		{[][]Lit{{-4, -1, 2}, {-4, 1, 3}, {4, -1, -2}, {4, 1, -3}}, 4}, // 4 = ITE(1, 2, 3)
+		{[][]Lit{{1, 2, -4}, {1, -2, 4}, {-1, 2, 4}, {-1, -2, -4}}, 4}, // 4 = 1 XOR 2
+		{[][]Lit{{1, 2, 4}, {-1, -2, -4}, {3, -4}}, 0},
+	}
+	for _, g := range gates {
+		solver := CreateSolver(len(g.clauses), 4)
+		for _, c := range g.clauses {
+			solver.AddClause(c)
+		}
+		e := solver.createEliminator()
+		if found := e.findGate(4); found != (g.size > 0) || len(e.gate) != g.size {
+			t.Errorf("found gate of %d clauses in %v", len(e.gate), g.clauses)
+		}
+	}
+}
+
+func TestEliminate(t *testing.T) {
+	solver := CreateSolver(4, 4)
+	solver.AddClause([]Lit{-4, 1})
+	solver.AddClause([]Lit{-4, 2})
+	solver.AddClause([]Lit{4, -1, -2})
+	solver.AddClause([]Lit{4, 3})
+	if !solver.eliminate(elimBudget) {
+		t.Fail()
+	}
+	if solver.stats.NumEliminated == 0 || solver.numEliminated != solver.stats.NumEliminated {
+		t.Fail()
+	}
+	// Eliminated variables are neither assigned nor branched on
+	for v := 1; v <= 4; v++ {
+		if solver.eliminated[v] && (solver.varValue(v) != LNULL || solver.decidable(v)) {
+			t.Fail()
+		}
+	}
+	if solver.NumAssigns() != 0 {
+		t.Fail()
+	}
+	if len(solver.extension) == 0 || len(solver.extension[0]) == 0 {
+		t.Fail()
+	}
+}
+
+func TestEliminateModel(t *testing.T) {
+	r := createRandom(3)
+	for n := 0; n < 20; n++ {
+		var clauses [][]Lit
+		solver := CreateSolver(150, 40)
+		for i := 0; i < 150; i++ {
+			clause := make([]Lit, 3)
+			for j := range clause {
+				clause[j] = Lit(r.intn(40) + 1)
+				if r.float() < 0.5 {
+					clause[j] = clause[j].negation()
+				}
+			}
+			clauses = append(clauses, clause)
+			solver.AddClause(append([]Lit(nil), clause...))
+		}
+		params := SolverParams{
+			MaxConflict:         1000,
+			MaxLearnts:          100,
+			VarActivityDecay:    0.95,
+			ClauseActivityDecay: 0.999,
+			Eliminate:           true,
+		}
+		res := solver.Search(params)
+		for res == LNULL {
+			res = solver.Search(params)
+		}
+		if res != LTRUE {
+			continue
+		}
+		model := solver.Model()
+		if len(model) != 40 {
+			t.Fatal("model does not assign every variable")
+		}
+		for _, c := range clauses {
+			satisfied := false
+			for _, l := range c {
+				satisfied = satisfied || model[l.variable()-1] == l
+			}
+			if !satisfied {
+				t.Fatalf("model falsifies %v", c)
+			}
+		}
+	}
+}
+
+func TestEliminateUnsat(t *testing.T) {
+	solver := createPigeonhole(4)
+	params := SolverParams{
+		MaxConflict:         1000,
+		MaxLearnts:          100,
+		VarActivityDecay:    0.95,
+		ClauseActivityDecay: 0.999,
+		Eliminate:           true,
+	}
+	res := solver.Search(params)
+	for res == LNULL {
+		res = solver.Search(params)
+	}
+	if res != LFALSE || solver.Model() != nil {
+		t.Fail()
+	}
+}
+
+func TestLearntBinaries(t *testing.T) {
+	solver := CreateSolver(2, 4)
+	solver.AddClause([]Lit{1, 2})
+	solver.AddClause([]Lit{3, 4})
+	solver.attach([]Lit{1, 3}, true)
+	solver.attach([]Lit{2, 4}, true)
+	if solver.NumClauses() != 2 || len(solver.irredundant()) != 2 || len(solver.Clauses()) != 2 {
+		t.FailNow()
+	}
+	// Learnt binary clauses survive unless they contain an eliminated variable
+	solver.eliminated[1] = true
+	if !solver.rebuild([][]Lit{{3, 4}}) || solver.NumClauses() != 1 {
+		t.FailNow()
+	}
+	if len(solver.binaries[Lit(-1).index()]) != 0 || len(solver.binaries[Lit(-3).index()]) != 1 {
+		t.Fail()
+	}
+	if bs := solver.binaries[Lit(-2).index()]; len(bs) != 1 || bs[0] != (binary{4, true}) {
+		t.Fail()
+	}
+}
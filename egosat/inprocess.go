@@ -0,0 +1,165 @@
+package egosat
+
+import "time"
+
+// These constants control the inprocessing scheduler.
+const (
+	inprocessGrowth = 1.5     // Factor by which the interval of a technique grows after each pass
+	inprocessBudget = 1 << 12 // Least budget of a pass, in propagation ticks or literals visited
+)
+
+// The technique struct describes a simplification technique run between
+// restarts by the inprocessing scheduler.
+type technique struct {
+	name     string                                // Name of the technique in the statistics
+	first    int                                   // Conflicts before the first pass
+	interval int                                   // Conflicts between the first pass and the second one
+	effort   float64                               // Fraction of the search propagation ticks a pass may spend
+	least    func(params *SolverParams) int        // Least budget of a pass, 0 if the technique is disabled
+	run      func(solver *Solver, budget int) bool // Runs a pass, returning false if the formula is unsatisfiable
+	stats    func(stats *SolverStats) *PassStats   // Statistics of the passes of the technique
+	effect   func(stats *SolverStats) int          // Number of simplifications made by the technique so far
+}
+
+// techniques lists the inprocessing techniques in the order in which they are
+// run when several passes are due. Probing runs at the first search already,
+// the other techniques start after preprocessing has had its effect.
+var techniques = []technique{
+	{
+		name:     "probing",
+		interval: 1000,
+		effort:   0.1,
+		least:    func(params *SolverParams) int { return params.ProbeBudget },
+		run:      (*Solver).probe,
+		stats:    func(stats *SolverStats) *PassStats { return &stats.Probing },
+		effect: func(stats *SolverStats) int {
+			return stats.NumFailed + stats.NumImplied + stats.NumHyperBinary
+		},
+	},
+	{
+		name:     "unhiding",
+		first:    5000,
+		interval: 5000,
+		effort:   0.05,
+		least:    enabledBy(func(params *SolverParams) bool { return params.Unhide }),
+		run:      (*Solver).unhide,
+		stats:    func(stats *SolverStats) *PassStats { return &stats.Unhiding },
+		effect: func(stats *SolverStats) int {
+			return stats.NumHiddenTautologies + stats.NumHiddenLiterals + stats.NumHiddenFailed + stats.NumSubstituted
+		},
+	},
+	{
+		name:     "vivification",
+		first:    2000,
+		interval: 2000,
+		effort:   0.1,
+		least:    enabledBy(func(params *SolverParams) bool { return params.Vivify }),
+		run:      (*Solver).vivify,
+		stats:    func(stats *SolverStats) *PassStats { return &stats.Vivification },
+		effect: func(stats *SolverStats) int {
+			return stats.NumVivifyShortened + stats.NumVivifyRemoved
+		},
+	},
+	{
+		name:     "subsumption",
+		first:    5000,
+		interval: 5000,
+		effort:   0.1,
+		least:    enabledBy(func(params *SolverParams) bool { return params.Subsume }),
+		run: func(solver *Solver, budget int) bool {
+			return solver.subsume(&solver.clauses, budget) && solver.subsume(&solver.learntClauses, budget)
+		},
+		stats: func(stats *SolverStats) *PassStats { return &stats.Subsumption },
+		effect: func(stats *SolverStats) int {
+			return stats.NumSubsumed + stats.NumStrengthened
+		},
+	},
+	{
+		name:     "elimination",
+		first:    10000,
+		interval: 10000,
+		effort:   0.1,
+		least:    enabledBy(func(params *SolverParams) bool { return params.Eliminate }),
+		run:      (*Solver).eliminate,
+		stats:    func(stats *SolverStats) *PassStats { return &stats.Elimination },
+		effect:   func(stats *SolverStats) int { return stats.NumEliminated },
+	},
+	{
+		name:     "substitution",
+		first:    10000,
+		interval: 10000,
+		effort:   0.05,
+		least:    enabledBy(func(params *SolverParams) bool { return params.Substitute }),
+		run:      (*Solver).substitute,
+		stats:    func(stats *SolverStats) *PassStats { return &stats.Substitution },
+		effect: func(stats *SolverStats) int {
+			return stats.NumSubstituted + stats.NumReduced
+		},
+	},
+}
+
+// enabledBy returns a least budget function giving inprocessBudget when the
+// parameters enable a technique.
+func enabledBy(enabled func(params *SolverParams) bool) func(params *SolverParams) int {
+	return func(params *SolverParams) int {
+		if enabled(params) {
+			return inprocessBudget
+		}
+		return 0
+	}
+}
+
+// The schedule struct records when the next pass of a technique is due.
+type schedule struct {
+	limit    int // Number of conflicts at which the next pass is due
+	interval int // Conflicts between the next pass and the one after
+	ticks    int // Search propagation ticks at the last pass
+}
+
+// createSchedules returns the schedules of the first passes of the techniques.
+func createSchedules() []schedule {
+	schedules := make([]schedule, len(techniques))
+	for i, t := range techniques {
+		schedules[i] = schedule{limit: t.first, interval: t.interval}
+	}
+	return schedules
+}
+
+// inprocess runs the passes of the enabled techniques which are due, at
+// decision level 0. A pass may spend the effort of its technique times the
+// search propagation ticks since its previous pass, which excludes the ticks of
+// inprocessing itself, and at least the least budget of the technique. After
+// each pass the interval of the technique grows geometrically. Passes are
+// skipped once Interrupt is invoked, and a running pass stops as if its budget
+// was spent. It returns false if the formula was found unsatisfiable.
+func (solver *Solver) inprocess() bool {
+	for i, t := range techniques {
+		s := &solver.schedules[i]
+		least := t.least(&solver.params)
+		if least == 0 || solver.stats.NumConflicts < s.limit || !solver.checkInterrupt() {
+			continue
+		}
+		solver.cancelUntil(0)
+		searchTicks := solver.stats.NumTicks - solver.inprocessTicks
+		budget := int(t.effort * float64(searchTicks-s.ticks))
+		if budget < least {
+			budget = least
+		}
+		pass := t.stats(&solver.stats)
+		effect := t.effect(&solver.stats)
+		ticks := solver.stats.NumTicks
+		start := time.Now()
+		ok := t.run(solver, budget)
+		pass.Passes++
+		pass.Time += time.Since(start)
+		pass.Effect += t.effect(&solver.stats) - effect
+		solver.inprocessTicks += solver.stats.NumTicks - ticks
+		s.ticks = searchTicks
+		s.limit = solver.stats.NumConflicts + s.interval
+		s.interval = int(float64(s.interval) * inprocessGrowth)
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package egosat
+
+import "testing"
+
+func TestInprocessSchedule(t *testing.T) {
+	solver := CreateSolver(3, 3)
+	solver.AddClause([]Lit{-1, 2})
+	solver.AddClause([]Lit{-1, 3})
+	solver.AddClause([]Lit{-2, -3})
+	solver.params = SolverParams{ProbeBudget: 100, Vivify: true}
+	if !solver.inprocess() {
+		t.FailNow()
+	}
+	stats := solver.Stats()
+	if stats.Probing.Passes != 1 || stats.Probing.Effect == 0 || stats.Vivification.Passes != 0 {
+		t.Fail()
+	}
+	if solver.schedules[0].limit != 1000 || solver.schedules[0].interval != 1500 {
+		t.Fail()
+	}
+	solver.stats.NumConflicts = 1000
+	if !solver.inprocess() || solver.Stats().Probing.Passes != 2 || solver.schedules[0].limit != 2500 {
+		t.Fail()
+	}
+}
+
+func TestInprocessModel(t *testing.T) {
+	r := createRandom(11)
+	eliminated := 0
+	for n := 0; n < 20; n++ {
+		var clauses [][]Lit
+		solver := CreateSolver(160, 40)
+		for i := 0; i < 160; i++ {
+			clause := make([]Lit, 3)
+			for j := range clause {
+				clause[j] = Lit(r.intn(40) + 1)
+				if r.float() < 0.5 {
+					clause[j] = clause[j].negation()
+				}
+			}
+			clauses = append(clauses, clause)
+			solver.AddClause(append([]Lit(nil), clause...))
+		}
+		// Every technique is due at every restart
+		for i := range solver.schedules {
+			solver.schedules[i] = schedule{interval: 1}
+		}
+		params := SolverParams{
+			MaxConflict:         10,
+			MaxLearnts:          100,
+			VarActivityDecay:    0.95,
+			ClauseActivityDecay: 0.999,
+			Eliminate:           true,
+			Subsume:             true,
+			ProbeBudget:         100,
+			Substitute:          true,
+			Vivify:              true,
+		}
+		res := solver.Search(params)
+		for res == LNULL {
+			res = solver.Search(params)
+		}
+		eliminated += solver.Stats().Elimination.Effect
+		if res != LTRUE {
+			continue
+		}
+		model := solver.Model()
+		for _, c := range clauses {
+			satisfied := false
+			for _, l := range c {
+				satisfied = satisfied || model[l.variable()-1] == l
+			}
+			if !satisfied {
+				t.Fatalf("model falsifies %v", c)
+			}
+		}
+	}
+	if eliminated == 0 {
+		t.Fail()
+	}
+}
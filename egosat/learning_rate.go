@@ -0,0 +1,245 @@
+package egosat
+
+// These constants control the step size of the exponential recency weighted
+// average used by the LRB and CHB heuristics. The step size starts at
+// stepSizeInit and decreases by stepSizeDec after every conflict until it
+// reaches stepSizeMin.
+const (
+	stepSizeInit = 0.4
+	stepSizeMin  = 0.06
+	stepSizeDec  = 1e-6
+)
+
+// The erwa struct contains the state shared by the learning rate heuristics,
+// which score variables with an exponential recency weighted average of a
+// reward. Only positive literals are kept in the priority queue, and decisions
+// use the phase saved by the solver.
+type erwa struct {
+	solver    *Solver   // Solver whose assignments and trail are consulted
+	score     []float64 // Score of every variable, indexed by Lit.index
+	order     *queue    // Priority queue of positive literals ordered by score
+	stepSize  float64   // Current step size of the moving average
+	conflicts int       // Number of conflicts seen by the heuristic
+	action    int       // Trail index of the first unprocessed assignment
+}
+
+// initERWA initializes the shared state for the variables of the solver.
+func (h *erwa) initERWA(solver *Solver) {
+	nVars := solver.NumVariables()
+	h.solver = solver
+	h.score = make([]float64, 2*nVars)
+	h.order = createQueue(h.score, nVars)
+	h.stepSize = stepSizeInit
+	for _, v := range solver.varOrder {
+		h.score[Lit(v).index()] = solver.initialActivity()
+		h.order.insert(Lit(v))
+	}
+}
+
+// reward moves the score of the variable towards the given reward.
+func (h *erwa) reward(v int, r float64) {
+	l := Lit(v)
+	h.score[l.index()] = h.stepSize*r + (1-h.stepSize)*h.score[l.index()]
+	if h.order.contains(l) {
+		h.order.moveUp(l)
+		h.order.moveDown(l)
+	}
+}
+
+// decay counts the conflict and decreases the step size.
+func (h *erwa) decay(factor float64) {
+	h.conflicts++
+	if h.stepSize > stepSizeMin {
+		h.stepSize -= stepSizeDec
+	}
+}
+
+// unassign returns the variable of the literal to the priority queue.
+func (h *erwa) unassign(lit Lit) {
+	l := Lit(lit.variable())
+	if !h.order.contains(l) {
+		h.order.insert(l)
+	}
+	if h.action > len(h.solver.trail) {
+		h.action = len(h.solver.trail)
+	}
+}
+
+// activate returns every decidable variable to the priority queue and skips
+// the assignments made while the heuristic was inactive.
+func (h *erwa) activate() {
+	for v := 1; v <= h.solver.NumVariables(); v++ {
+		if h.solver.decidable(v) && !h.order.contains(Lit(v)) {
+			h.order.insert(Lit(v))
+		}
+	}
+	h.action = len(h.solver.trail)
+}
+
+// peek returns the positive literal of the highest scoring decidable variable,
+// discarding assigned and eliminated variables from the top of the priority
+// queue.
+func (h *erwa) peek() Lit {
+	for {
+		l := h.order.max()
+		if h.solver.decidable(l.variable()) {
+			return l
+		}
+		h.order.removeMax()
+	}
+}
+
+// priority returns the score of the variable of the literal.
+func (h *erwa) priority(lit Lit) float64 {
+	return h.score[Lit(lit.variable()).index()]
+}
+
+// next selects the highest scoring decidable variable with its saved phase.
+func (h *erwa) next() Lit {
+	for {
+		l := h.order.removeMax()
+		if h.solver.decidable(l.variable()) {
+			return h.solver.phaseLit(l.variable())
+		}
+	}
+}
+
+// The lrb struct implements the Learning Rate Branching heuristic. The reward
+// of a variable is the fraction of the conflicts during its assignment in which
+// it participated, either in the conflict analysis or in the reason of a
+// literal of the learnt clause.
+type lrb struct {
+	erwa
+	assigned     []int  // Number of conflicts when the variable was assigned
+	participated []int  // Conflicts in which the variable was analyzed since
+	reasoned     []int  // Conflicts in which the variable was a reason since
+	bumped       []bool // Marks variables analyzed in the current conflict
+	pending      []int  // Variables analyzed in the current conflict
+}
+
+// createLRB creates a new LRB brancher for the variables of the solver.
+func createLRB(solver *Solver) *lrb {
+	nVars := solver.NumVariables()
+	h := &lrb{
+		assigned:     make([]int, nVars+1),
+		participated: make([]int, nVars+1),
+		reasoned:     make([]int, nVars+1),
+		bumped:       make([]bool, nVars+1),
+	}
+	h.initERWA(solver)
+	return h
+}
+
+// bump counts the participation of the variable in the current conflict.
+func (h *lrb) bump(lit Lit) {
+	v := lit.variable()
+	if !h.bumped[v] {
+		h.bumped[v] = true
+		h.participated[v]++
+		h.pending = append(h.pending, v)
+	}
+}
+
+// learnt counts the variables in the reasons of the literals of the learnt
+// clause which did not otherwise participate in the conflict.
+func (h *lrb) learnt(lits []Lit) {
+	for _, l := range lits[1:] {
+		reason := h.solver.reasonClause(l.negation())
+		if reason == noClause {
+			continue
+		}
+		for i := 0; i < h.solver.arena.size(reason); i++ {
+			v := h.solver.arena.lit(reason, i).variable()
+			if !h.bumped[v] {
+				h.bumped[v] = true
+				h.reasoned[v]++
+				h.pending = append(h.pending, v)
+			}
+		}
+	}
+}
+
+// decay clears the variables of the current conflict and counts the conflict.
+func (h *lrb) decay(factor float64) {
+	for _, v := range h.pending {
+		h.bumped[v] = false
+	}
+	h.pending = h.pending[:0]
+	h.erwa.decay(factor)
+}
+
+// propagated starts the participation counts of newly assigned variables.
+func (h *lrb) propagated(conflict bool) {
+	for ; h.action < len(h.solver.trail); h.action++ {
+		v := h.solver.trail[h.action].variable()
+		h.assigned[v] = h.conflicts
+		h.participated[v] = 0
+		h.reasoned[v] = 0
+	}
+}
+
+// unassign rewards the variable of the literal with its learning rate over the
+// interval for which it was assigned.
+func (h *lrb) unassign(lit Lit) {
+	v := lit.variable()
+	if interval := h.conflicts - h.assigned[v]; interval > 0 {
+		r := float64(h.participated[v]+h.reasoned[v]) / float64(interval)
+		h.reward(v, r)
+	}
+	h.erwa.unassign(lit)
+}
+
+// The chb struct implements the Conflict History-Based Branching heuristic.
+// Whenever a variable is assigned by propagation or analyzed in a conflict, it
+// is rewarded in inverse proportion to the number of conflicts since it last
+// took part in a conflict. The reward is larger if a conflict was found.
+type chb struct {
+	erwa
+	lastConflict []int // Number of the last conflict in which the variable was analyzed
+	pending      []int // Variables analyzed in the current conflict
+}
+
+// createCHB creates a new CHB brancher for the variables of the solver.
+func createCHB(solver *Solver) *chb {
+	h := &chb{lastConflict: make([]int, solver.NumVariables()+1)}
+	h.initERWA(solver)
+	return h
+}
+
+// bump records that the variable took part in the current conflict.
+func (h *chb) bump(lit Lit) {
+	v := lit.variable()
+	if h.lastConflict[v] != h.conflicts+1 {
+		h.lastConflict[v] = h.conflicts + 1
+		h.pending = append(h.pending, v)
+	}
+}
+
+// learnt gives the variables analyzed in the current conflict the conflict
+// reward. They have just taken part in a conflict, so their age is 1.
+func (h *chb) learnt(lits []Lit) {
+	for _, v := range h.pending {
+		h.reward(v, 1.0)
+	}
+	h.pending = h.pending[:0]
+}
+
+// decay clears the variables of the current conflict and counts the conflict.
+func (h *chb) decay(factor float64) {
+	h.pending = h.pending[:0]
+	h.erwa.decay(factor)
+}
+
+// propagated rewards the variables assigned since the last propagation. The
+// reward is larger if the propagation ended in a conflict.
+func (h *chb) propagated(conflict bool) {
+	multiplier := 0.9
+	if conflict {
+		multiplier = 1.0
+	}
+	for ; h.action < len(h.solver.trail); h.action++ {
+		v := h.solver.trail[h.action].variable()
+		age := h.conflicts - h.lastConflict[v] + 1
+		h.reward(v, multiplier/float64(age))
+	}
+}
@@ -0,0 +1,80 @@
+package egosat
+
+import "testing"
+
+func TestLRB(t *testing.T) {
+	solver := CreateSolver(10, 3)
+	solver.AddClause([]Lit{-1, 2})
+	solver.useHeuristic(LRB)
+	h := solver.brancher.(*lrb)
+	solver.assume(Lit(1))
+	solver.propagate()
+	h.propagated(false)
+	if h.action != 2 || h.assigned[2] != 0 {
+		t.Fail()
+	}
+	// Variable 2 participates in a conflict while 1 is only a reason for it
+	h.bump(Lit(2))
+	h.bump(Lit(2))
+	h.learnt([]Lit{-3, 2})
+	h.decay(1)
+	if h.participated[2] != 1 || h.participated[1] != 0 || h.reasoned[1] != 1 {
+		t.Fail()
+	}
+	if h.conflicts != 1 || h.bumped[1] || h.bumped[2] {
+		t.Fail()
+	}
+	// Unassigning rewards both variables with their learning rates
+	solver.cancelUntil(0)
+	if h.action != 0 {
+		t.Fail()
+	}
+	if h.score[Lit(1).index()] != h.stepSize || h.score[Lit(2).index()] != h.stepSize {
+		t.Fail()
+	}
+	if h.score[Lit(3).index()] != 0 {
+		t.Fail()
+	}
+}
+
+func TestCHB(t *testing.T) {
+	solver := CreateSolver(10, 3)
+	solver.useHeuristic(CHB)
+	h := solver.brancher.(*chb)
+	h.bump(Lit(-2))
+	h.decay(1)
+	solver.assume(Lit(1))
+	solver.assume(Lit(2))
+	h.propagated(true)
+	// Variable 2 took part in the last conflict and receives the full reward
+	if h.score[Lit(2).index()] != h.stepSize {
+		t.Fail()
+	}
+	if h.score[Lit(1).index()] != h.stepSize/2 {
+		t.Fail()
+	}
+	if l := h.next(); l != Lit(-3) {
+		t.Fail()
+	}
+}
+
+func TestCHBConflict(t *testing.T) {
+	solver := CreateSolver(10, 3)
+	solver.useHeuristic(CHB)
+	h := solver.brancher.(*chb)
+	h.bump(Lit(-2))
+	h.bump(Lit(2))
+	h.bump(Lit(3))
+	h.learnt([]Lit{-2, 3})
+	// The analyzed variables receive the conflict reward once
+	if h.score[Lit(2).index()] != h.stepSize || h.score[Lit(3).index()] != h.stepSize {
+		t.Fail()
+	}
+	if h.score[Lit(1).index()] != 0 || len(h.pending) != 0 {
+		t.Fail()
+	}
+	h.decay(1)
+	if h.conflicts != 1 || h.lastConflict[2] != 1 || h.lastConflict[1] != 0 {
+		t.Fail()
+	}
+}
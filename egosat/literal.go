@@ -1,11 +1,11 @@
 package egosat
 
 // Lit type is used to represent literals, i.e. a variable or it's negation.
-type Lit int
+type Lit int32
 
 // Lbool is used to represent boolean values with the possibility of null,
 // or undetermined.
-type Lbool int
+type Lbool uint8
 
 const (
 	// LNULL indicates a non-true, non-false boolean value.
@@ -35,7 +35,7 @@ func (lit Lit) variable() int {
 
 // negation returns the negation of this literal.
 func (lit Lit) negation() Lit {
-	return -1 * lit
+	return -lit
 }
 
 // index returns the index associated with this literal in the solver data
@@ -47,3 +47,13 @@ func (lit Lit) index() (idx int) {
 	}
 	return
 }
+
+// indexLit returns the literal associated with the given index in the solver
+// data structures, i.e. the inverse of Lit.index.
+func indexLit(idx int) Lit {
+	lit := Lit(idx/2 + 1)
+	if idx%2 == 1 {
+		lit = lit.negation()
+	}
+	return lit
+}
@@ -0,0 +1,68 @@
+package egosat
+
+import "errors"
+
+// ErrMemoryLimit is returned by Err when a search gave up because the estimated
+// memory usage of the solver exceeded MemoryLimit.
+var ErrMemoryLimit = errors.New("memory limit exceeded")
+
+const (
+	memoryCheckInterval = 100 // Conflicts between two estimations of the memory usage
+	memoryReduce        = 0.8 // Fraction of MemoryLimit above which learnt clauses are trimmed
+)
+
+// Estimated sizes in bytes of the data structures of the solver. Per variable
+// arrays sum the sizes of assignments, reasons, seen, level, the three phases,
+// varOrder, levelStamps and the trail, and every brancher is assumed to use
+// about as much for its activities, queue or heap.
+const (
+	sliceBytes       = 24
+	watcherBytes     = 8
+	binaryBytes      = 8
+	variableBytes    = 1 + 8 + 1 + 8 + 3 + 8 + 8 + 4
+	brancherVarBytes = 48
+)
+
+// memoryUsage estimates the number of bytes used by the clause arena, the
+// watcher and implication lists and the per variable arrays of the solver.
+// Capacities are counted rather than lengths, since that is what is allocated.
+func (solver *Solver) memoryUsage() int {
+	nVars := solver.NumVariables()
+	usage := 4*(cap(solver.arena.mem)+cap(solver.spare)) +
+		4*(cap(solver.clauses)+cap(solver.learntClauses)) +
+		variableBytes*nVars
+	for i := range solver.watcherLists {
+		usage += sliceBytes + watcherBytes*cap(solver.watcherLists[i])
+		usage += sliceBytes + binaryBytes*cap(solver.binaries[i])
+	}
+	for _, b := range solver.branchers {
+		if b != nil {
+			usage += brancherVarBytes * nVars
+		}
+	}
+	return usage
+}
+
+// checkMemory records the estimated memory usage in the stats and enforces the
+// MemoryLimit parameter. Above memoryReduce of the limit, the learnt clauses
+// are trimmed regardless of MaxLearnts and the arena is compacted without
+// keeping the previous one for reuse. If the usage still exceeds the limit,
+// the search gives up with ErrMemoryLimit and false is returned.
+func (solver *Solver) checkMemory() bool {
+	solver.stats.MemoryUsage = solver.memoryUsage()
+	limit := solver.params.MemoryLimit
+	if limit <= 0 || float64(solver.stats.MemoryUsage) <= memoryReduce*float64(limit) {
+		return true
+	}
+	solver.trimLearnts()
+	solver.spare = nil
+	solver.compactArena()
+	solver.spare = nil
+	solver.stats.NumMemoryReduce++
+	solver.stats.MemoryUsage = solver.memoryUsage()
+	if solver.stats.MemoryUsage > limit {
+		solver.err = ErrMemoryLimit
+		return false
+	}
+	return true
+}
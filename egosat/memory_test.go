@@ -0,0 +1,44 @@
+package egosat
+
+import "testing"
+
+func TestMemoryUsage(t *testing.T) {
+	solver := createPigeonhole(6)
+	usage := solver.memoryUsage()
+	if usage < 4*len(solver.arena.mem) {
+		t.Fail()
+	}
+	solver.addLearnt([]Lit{1, 2, 3, 4, 5})
+	if solver.memoryUsage() <= usage {
+		t.Fail()
+	}
+}
+
+func TestMemoryLimit(t *testing.T) {
+	solver := createPigeonhole(8)
+	params := SolverParams{
+		MaxConflict:         1000,
+		MaxLearnts:          1 << 20,
+		VarActivityDecay:    0.8,
+		ClauseActivityDecay: 0.999,
+		MemoryLimit:         solver.memoryUsage() * 2,
+	}
+	var res Lbool
+	for i := 0; i < 100 && res == LNULL && solver.Err() == nil; i++ {
+		res = solver.Search(params)
+	}
+	if res != LNULL || solver.Err() != ErrMemoryLimit {
+		t.Fatal("search did not give up on the memory limit")
+	}
+	stats := solver.Stats()
+	if stats.NumMemoryReduce == 0 || stats.MemoryUsage <= params.MemoryLimit {
+		t.Fail()
+	}
+	// Searching without a limit clears the error
+	params.MemoryLimit = 0
+	params.MaxConflict = 1
+	solver.Search(params)
+	if solver.Err() != nil {
+		t.Fail()
+	}
+}
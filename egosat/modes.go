@@ -0,0 +1,108 @@
+package egosat
+
+// These constants control restarts and mode switching when the solver
+// alternates between focused and stable mode.
+const (
+	modeGrowth    = 2.0  // Factor by which the ticks budget of a mode grows
+	lbdFastAlpha  = 0.03 // Smoothing factor of the fast moving average of LBDs
+	lbdSlowAlpha  = 1e-5 // Smoothing factor of the slow moving average of LBDs
+	restartMargin = 1.1  // Ratio of fast to slow LBD average forcing a restart
+)
+
+// The ema struct computes an exponential moving average. The average is
+// corrected for its initial bias towards zero, so that it is meaningful from
+// the first update onwards.
+type ema struct {
+	value  float64 // Bias corrected moving average
+	biased float64 // Moving average biased towards zero
+	exp    float64 // Remaining weight of the initial zero value
+	alpha  float64 // Smoothing factor
+}
+
+// createEMA creates a new moving average with the given smoothing factor.
+func createEMA(alpha float64) ema {
+	return ema{exp: 1, alpha: alpha}
+}
+
+// update adds a sample to the moving average.
+func (e *ema) update(x float64) {
+	e.biased += e.alpha * (x - e.biased)
+	e.exp *= 1 - e.alpha
+	e.value = e.biased / (1 - e.exp)
+}
+
+// luby returns the i-th element of the Luby sequence 1, 1, 2, 1, 1, 2, 4, ...
+// counting from zero.
+func luby(i int) int {
+	size, seq := 1, 0
+	for size < i+1 {
+		seq++
+		size = 2*size + 1
+	}
+	for size-1 != i {
+		size = (size - 1) >> 1
+		seq--
+		i = i % size
+	}
+	return 1 << seq
+}
+
+// modeHeuristic returns the decision heuristic of the current mode.
+func (solver *Solver) modeHeuristic() Heuristic {
+	if solver.stable && solver.params.ModeInterval > 0 {
+		return solver.params.StableHeuristic
+	}
+	return solver.params.Heuristic
+}
+
+// restartDue reports whether the search should restart after the given number
+// of conflicts. Without mode switching the search restarts after MaxConflict
+// conflicts. Otherwise stable mode restarts according to the Luby sequence and
+// focused mode restarts whenever recently learnt clauses have a notably higher
+// LBD than the long term average.
+func (solver *Solver) restartDue(numConflicts int) bool {
+	if solver.params.ModeInterval <= 0 {
+		return numConflicts > solver.params.MaxConflict
+	}
+	if solver.stable {
+		unit := solver.params.LubyUnit
+		if unit < 1 {
+			unit = 1
+		}
+		return numConflicts >= unit*luby(solver.lubyIndex)
+	}
+	return numConflicts >= 2 && solver.lbdFast.value > restartMargin*solver.lbdSlow.value
+}
+
+// modeSwitchDue reports whether the current mode has used up its budget. The
+// first mode runs for ModeInterval conflicts, and the number of propagation
+// ticks it used becomes the budget of the next mode. Every further mode gets
+// modeGrowth times the ticks of the previous one.
+func (solver *Solver) modeSwitchDue() bool {
+	if solver.params.ModeInterval <= 0 {
+		return false
+	}
+	if solver.modeLimit == 0 {
+		return solver.stats.NumConflicts-solver.modeConflicts >= solver.params.ModeInterval
+	}
+	return solver.stats.NumTicks-solver.modeTicks >= solver.modeLimit
+}
+
+// switchMode switches between focused and stable mode. It must be invoked at
+// decision level 0.
+func (solver *Solver) switchMode() {
+	if solver.modeLimit == 0 {
+		solver.modeLimit = solver.stats.NumTicks - solver.modeTicks
+		if solver.modeLimit < 1 {
+			solver.modeLimit = 1
+		}
+	} else {
+		solver.modeLimit = int(float64(solver.modeLimit) * modeGrowth)
+	}
+	solver.stable = !solver.stable
+	solver.modeConflicts = solver.stats.NumConflicts
+	solver.modeTicks = solver.stats.NumTicks
+	solver.lubyIndex = 0
+	solver.stats.NumModeSwitch++
+	solver.useHeuristic(solver.modeHeuristic())
+}
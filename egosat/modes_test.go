@@ -0,0 +1,123 @@
+package egosat
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLuby(t *testing.T) {
+	expected := []int{1, 1, 2, 1, 1, 2, 4, 1, 1, 2, 1, 1, 2, 4, 8, 1}
+	for i, e := range expected {
+		if luby(i) != e {
+			t.Fail()
+		}
+	}
+}
+
+func TestEMA(t *testing.T) {
+	e := createEMA(0.1)
+	e.update(5)
+	if math.Abs(e.value-5) > 1e-9 {
+		t.Fail()
+	}
+	for i := 0; i < 1000; i++ {
+		e.update(2)
+	}
+	if math.Abs(e.value-2) > 1e-9 {
+		t.Fail()
+	}
+}
+
+func TestRestartDue(t *testing.T) {
+	solver := CreateSolver(10, 3)
+	solver.configure(SolverParams{MaxConflict: 10})
+	if solver.restartDue(10) || !solver.restartDue(11) {
+		t.Fail()
+	}
+	solver.configure(SolverParams{ModeInterval: 100, LubyUnit: 10})
+	// Focused mode restarts when the fast average exceeds the slow one
+	solver.lbdFast.value, solver.lbdSlow.value = 5, 5
+	if solver.restartDue(5) {
+		t.Fail()
+	}
+	solver.lbdFast.value = 6
+	if !solver.restartDue(5) {
+		t.Fail()
+	}
+	// Stable mode restarts following the Luby sequence
+	solver.stable = true
+	solver.lubyIndex = 2
+	if solver.restartDue(19) || !solver.restartDue(20) {
+		t.Fail()
+	}
+}
+
+func TestSwitchMode(t *testing.T) {
+	solver := CreateSolver(10, 3)
+	solver.configure(SolverParams{ModeInterval: 2, Heuristic: VMTF, StableHeuristic: VSIDS})
+	focused := solver.brancher
+	if _, ok := focused.(*vmtf); !ok {
+		t.Fail()
+	}
+	solver.stats.NumConflicts = 1
+	if solver.modeSwitchDue() {
+		t.Fail()
+	}
+	solver.stats.NumConflicts = 2
+	solver.stats.NumTicks = 40
+	if !solver.modeSwitchDue() {
+		t.Fail()
+	}
+	solver.switchMode()
+	if _, ok := solver.brancher.(*vsids); !ok || !solver.stable || solver.modeLimit != 40 {
+		t.Fail()
+	}
+	// Later modes are limited by ticks and their budget grows
+	solver.stats.NumConflicts = 100
+	if solver.modeSwitchDue() {
+		t.Fail()
+	}
+	solver.stats.NumTicks = 80
+	if !solver.modeSwitchDue() {
+		t.Fail()
+	}
+	solver.switchMode()
+	if solver.brancher != focused || solver.stable || solver.modeLimit != 80 {
+		t.Fail()
+	}
+	if solver.stats.NumModeSwitch != 2 {
+		t.Fail()
+	}
+}
+
+func TestSearchModes(t *testing.T) {
+	// Four pigeons do not fit into three holes
+	solver := CreateSolver(30, 12)
+	pigeon := func(p, h int) Lit { return Lit(3*p + h + 1) }
+	for p := 0; p < 4; p++ {
+		solver.AddClause([]Lit{pigeon(p, 0), pigeon(p, 1), pigeon(p, 2)})
+	}
+	for h := 0; h < 3; h++ {
+		for p := 0; p < 4; p++ {
+			for q := p + 1; q < 4; q++ {
+				solver.AddClause([]Lit{-pigeon(p, h), -pigeon(q, h)})
+			}
+		}
+	}
+	params := SolverParams{
+		MaxLearnts:          100,
+		VarActivityDecay:    0.95,
+		ClauseActivityDecay: 0.999,
+		Heuristic:           VMTF,
+		StableHeuristic:     VSIDS,
+		ModeInterval:        2,
+		LubyUnit:            1,
+	}
+	res := LNULL
+	for i := 0; i < 1000 && res == LNULL; i++ {
+		res = solver.Search(params)
+	}
+	if res != LFALSE || solver.stats.NumModeSwitch == 0 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,105 @@
+package egosat
+
+// rephaseKind identifies how the saved phases are reset by a rephase.
+type rephaseKind int
+
+const (
+	rephaseOriginal = rephaseKind(iota) // Reset every phase to false
+	rephaseInverted                     // Reset every phase to true
+	rephaseBest                         // Reset the phases to the best phases
+	rephaseRandom                       // Reset the phases randomly
+	rephaseWalk                         // Reset the phases by local search
+)
+
+// rephaseSchedule is the order in which rephases are applied. The first two
+// entries are only used once, after which the schedule cycles through the
+// remaining entries.
+var rephaseSchedule = []rephaseKind{
+	rephaseOriginal, rephaseInverted,
+	rephaseBest, rephaseWalk, rephaseOriginal,
+	rephaseBest, rephaseWalk, rephaseInverted,
+	rephaseBest, rephaseRandom,
+}
+
+// updatePhases records the assignments of the first n literals of the trail,
+// which are known not to be in conflict, as target phases if the trail is
+// longer than the previous target, and as best phases if it is longer than the
+// previous best.
+func (solver *Solver) updatePhases(n int) {
+	if n > solver.targetAssigned {
+		solver.copyTrail(solver.target, n)
+		solver.targetAssigned = n
+	}
+	if n > solver.bestAssigned {
+		solver.copyTrail(solver.best, n)
+		solver.bestAssigned = n
+	}
+}
+
+// copyTrail copies the values of the first n literals of the trail into the
+// given phases.
+func (solver *Solver) copyTrail(phases []Lbool, n int) {
+	for _, l := range solver.trail[:n] {
+		phases[l.variable()] = l.polarity()
+	}
+}
+
+// decisionLit returns the literal to assume for a decision on the variable of
+// the given literal. In stable mode the target phase is preferred, otherwise
+// the polarity chosen by the heuristic is kept.
+func (solver *Solver) decisionLit(lit Lit) Lit {
+	v := lit.variable()
+	if !solver.stable {
+		return lit
+	}
+	switch solver.target[v] {
+	case LTRUE:
+		return Lit(v)
+	case LFALSE:
+		return Lit(-v)
+	}
+	return lit
+}
+
+// rephase resets the saved phases according to the next entry of the rephase
+// schedule and schedules the next rephase. The target phases are reset to the
+// new saved phases.
+func (solver *Solver) rephase() {
+	i := solver.stats.NumRephases
+	if i >= len(rephaseSchedule) {
+		i = 2 + (i-2)%(len(rephaseSchedule)-2)
+	}
+	nVars := solver.NumVariables()
+	switch rephaseSchedule[i] {
+	case rephaseOriginal, rephaseInverted:
+		val := LFALSE
+		if rephaseSchedule[i] == rephaseInverted {
+			val = LTRUE
+		}
+		for v := 1; v <= nVars; v++ {
+			solver.phases[v] = val
+		}
+	case rephaseBest:
+		for v := 1; v <= nVars; v++ {
+			if solver.best[v] != LNULL {
+				solver.phases[v] = solver.best[v]
+			}
+		}
+		solver.bestAssigned = 0
+	case rephaseRandom:
+		for v := 1; v <= nVars; v++ {
+			solver.phases[v] = LFALSE
+			if solver.rng.float() < 0.5 {
+				solver.phases[v] = LTRUE
+			}
+		}
+	case rephaseWalk:
+		solver.cancelUntil(0)
+		solver.walk(walkFlipsPerClause * solver.NumClauses())
+	}
+	copy(solver.target, solver.phases)
+	solver.targetAssigned = 0
+	solver.stats.NumRephases++
+	solver.rephaseLimit = solver.stats.NumConflicts +
+		solver.params.RephaseInterval*(solver.stats.NumRephases+1)
+}
@@ -0,0 +1,105 @@
+package egosat
+
+import "testing"
+
+func TestUpdatePhases(t *testing.T) {
+	solver := CreateSolver(10, 4)
+	solver.assume(Lit(1))
+	solver.assume(Lit(-2))
+	solver.updatePhases(2)
+	if solver.targetAssigned != 2 || solver.bestAssigned != 2 {
+		t.Fail()
+	}
+	if solver.target[1] != LTRUE || solver.target[2] != LFALSE || solver.best[2] != LFALSE {
+		t.Fail()
+	}
+	// Shorter trails do not replace the target or best phases
+	solver.cancelUntil(0)
+	solver.assume(Lit(-1))
+	solver.updatePhases(1)
+	if solver.target[1] != LTRUE || solver.best[1] != LTRUE {
+		t.Fail()
+	}
+	// In stable mode decisions follow the target phases
+	if solver.decisionLit(Lit(-1)) != Lit(-1) {
+		t.Fail()
+	}
+	solver.stable = true
+	if solver.decisionLit(Lit(-1)) != Lit(1) || solver.decisionLit(Lit(3)) != Lit(3) {
+		t.Fail()
+	}
+}
+
+func TestRephase(t *testing.T) {
+	solver := CreateSolver(10, 3)
+	solver.AddClause([]Lit{1, 2})
+	solver.AddClause([]Lit{-1, -3})
+	solver.configure(SolverParams{RephaseInterval: 10})
+	solver.best = []Lbool{LNULL, LTRUE, LFALSE, LNULL}
+	kinds := []rephaseKind{}
+	for i := 0; i < len(rephaseSchedule)+3; i++ {
+		solver.rephase()
+		i := solver.stats.NumRephases - 1
+		if i >= len(rephaseSchedule) {
+			i = 2 + (i-2)%(len(rephaseSchedule)-2)
+		}
+		kinds = append(kinds, rephaseSchedule[i])
+		switch rephaseSchedule[i] {
+		case rephaseOriginal:
+			if solver.phases[1] != LFALSE || solver.phases[3] != LFALSE {
+				t.Fail()
+			}
+		case rephaseInverted:
+			if solver.phases[1] != LTRUE || solver.phases[3] != LTRUE {
+				t.Fail()
+			}
+		case rephaseBest:
+			if solver.phases[1] != LTRUE || solver.phases[2] != LFALSE {
+				t.Fail()
+			}
+		case rephaseWalk:
+			if solver.phases[1] == solver.phases[3] && solver.phases[1] == LTRUE {
+				t.Fail()
+			}
+		}
+		if solver.targetAssigned != 0 || solver.target[1] != solver.phases[1] {
+			t.Fail()
+		}
+	}
+	if kinds[0] != rephaseOriginal || kinds[1] != rephaseInverted {
+		t.Fail()
+	}
+	if kinds[len(rephaseSchedule)] != rephaseSchedule[2] {
+		t.Fail()
+	}
+	if solver.rephaseLimit != 10*(len(kinds)+1) {
+		t.Fail()
+	}
+}
+
+func TestRandomInitDecisions(t *testing.T) {
+	solver := CreateSolver(0, 20)
+	solver.configure(SolverParams{Heuristic: VSIDS, RandomInit: true, Seed: 5})
+	positive := 0
+	for i := 0; i < 20; i++ {
+		l := solver.decisionLit(solver.pickLit())
+		if l != solver.phaseLit(l.variable()) {
+			t.Fail()
+		}
+		if l > 0 {
+			positive++
+		}
+		solver.assume(l)
+	}
+	// The random initial phases give decisions of both polarities
+	if positive == 0 || positive == 20 {
+		t.Fail()
+	}
+	// Without RandomInit the polarity follows the literal activities
+	solver = CreateSolver(0, 2)
+	solver.configure(SolverParams{Heuristic: VSIDS})
+	solver.brancher.bump(Lit(2))
+	if solver.pickLit() != Lit(2) {
+		t.Fail()
+	}
+}
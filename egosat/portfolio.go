@@ -0,0 +1,114 @@
+package egosat
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrInterrupted is returned by Err when a search gave up because Interrupt
+// was invoked.
+var ErrInterrupted = errors.New("search interrupted")
+
+// Interrupt makes the current search of the solver give up as soon as
+// possible, and every later search give up right away, with ErrInterrupted. It
+// is the only method which may be invoked while another goroutine is running a
+// search.
+func (solver *Solver) Interrupt() {
+	atomic.StoreInt32(&solver.interrupted, 1)
+}
+
+// checkInterrupt reports whether the search may continue, setting the error of
+// the search to ErrInterrupted if Interrupt was invoked.
+func (solver *Solver) checkInterrupt() bool {
+	if atomic.LoadInt32(&solver.interrupted) == 0 {
+		return true
+	}
+	solver.err = ErrInterrupted
+	return false
+}
+
+// These settings are combined to diversify the workers of a portfolio. The
+// numbers of settings are coprime or cycled at different rates, so that
+// neighbouring workers differ in several ways.
+var (
+	portfolioHeuristics = []Heuristic{VSIDS, VMTF, LRB, CHB}
+	portfolioRestarts   = []func(params *SolverParams){
+		func(params *SolverParams) {},                             // Restarts growing geometrically
+		func(params *SolverParams) { params.ModeInterval = 1000 }, // Switching between focused and stable mode
+		func(params *SolverParams) { // Stable mode, keeping the trail at restarts
+			params.Stable = true
+			params.ReuseTrail = true
+		},
+	}
+	portfolioPhases = []func(params *SolverParams){
+		func(params *SolverParams) {},                                // Saved phases
+		func(params *SolverParams) { params.RandomInit = true },      // Random initial phases
+		func(params *SolverParams) { params.RephaseInterval = 1000 }, // Rephasing
+	}
+)
+
+// PortfolioParams returns the parameters of n workers of a portfolio. The first
+// worker gets the given parameters, and every other worker a different seed
+// and shuffled variables, with its decision heuristic, restart policy and phase
+// selection taken in turn from the settings of the portfolio.
+func PortfolioParams(params SolverParams, n int) []SolverParams {
+	workers := make([]SolverParams, n)
+	workers[0] = params
+	for i := 1; i < n; i++ {
+		p := params
+		p.Seed = params.Seed + uint64(i)
+		p.Shuffle = true
+		p.Heuristic = portfolioHeuristics[i%len(portfolioHeuristics)]
+		portfolioRestarts[i%len(portfolioRestarts)](&p)
+		portfolioPhases[i/len(portfolioHeuristics)%len(portfolioPhases)](&p)
+		workers[i] = p
+	}
+	return workers
+}
+
+// SolvePortfolio solves a formula with one worker per parameters, each running
+// Solve in its own goroutine on a solver built by create, which is invoked
+// concurrently. Workers with a positive ShareSize exchange their short learnt
+// clauses and their units, which a proof written by a worker does not justify.
+// The first definitive answer interrupts the other workers. Once
+// all workers have stopped, it returns their solvers, whose statistics remain
+// available, the index of the worker which decided the formula, or -1 if all
+// of them gave up, and its answer.
+func SolvePortfolio(create func() *Solver, params []SolverParams) ([]*Solver, int, Lbool) {
+	solvers := make([]*Solver, len(params))
+	var wg sync.WaitGroup
+	for i := range solvers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			solvers[i] = create()
+		}(i)
+	}
+	wg.Wait()
+	connect(solvers)
+	type answer struct {
+		worker int
+		res    Lbool
+	}
+	answers := make(chan answer, len(solvers))
+	for i, solver := range solvers {
+		go func(i int, solver *Solver) {
+			answers <- answer{i, solver.Solve(params[i])}
+		}(i, solver)
+	}
+	winner, res := -1, LNULL
+	for range solvers {
+		a := <-answers
+		if a.res == LNULL || winner >= 0 {
+			continue
+		}
+		winner, res = a.worker, a.res
+		for j, solver := range solvers {
+			if j != winner {
+				solver.Interrupt()
+			}
+		}
+	}
+	return solvers, winner, res
+}
@@ -0,0 +1,99 @@
+package egosat
+
+import "testing"
+
+func TestInterrupt(t *testing.T) {
+	solver := CreateSolver(2, 2)
+	solver.AddClause([]Lit{1, 2})
+	solver.Interrupt()
+	if solver.Search(SolverParams{MaxConflict: 10}) != LNULL || solver.Err() != ErrInterrupted {
+		t.Fail()
+	}
+}
+
+func TestInterruptPasses(t *testing.T) {
+	solver := CreateSolver(4, 4)
+	solver.AddClause([]Lit{-4, 1})
+	solver.AddClause([]Lit{-4, 2})
+	solver.AddClause([]Lit{4, -1, -2})
+	solver.AddClause([]Lit{4, 3})
+	solver.configure(SolverParams{Eliminate: true})
+	solver.Interrupt()
+	// Passes stop as if their budget was spent
+	if !solver.eliminate(elimBudget) || solver.stats.NumEliminated != 0 || solver.Err() != ErrInterrupted {
+		t.Fail()
+	}
+	if !solver.preprocess() || solver.stats.NumEliminated != 0 {
+		t.Fail()
+	}
+	solver.walk(100)
+	for v := 1; v <= 4; v++ {
+		if solver.phases[v] != LFALSE {
+			t.Fail()
+		}
+	}
+}
+
+func TestPortfolioParams(t *testing.T) {
+	base := SolverParams{Seed: 7, Heuristic: LRB}
+	params := PortfolioParams(base, 6)
+	if len(params) != 6 || params[0] != base {
+		t.FailNow()
+	}
+	for i, p := range params[1:] {
+		if p.Seed != base.Seed+uint64(i+1) || !p.Shuffle {
+			t.Fail()
+		}
+	}
+	if params[1].Heuristic != VMTF || params[1].ModeInterval == 0 || !params[2].Stable || !params[5].RandomInit {
+		t.Fail()
+	}
+}
+
+func TestSolvePortfolio(t *testing.T) {
+	r := createRandom(17)
+	for n := 0; n < 10; n++ {
+		var clauses [][]Lit
+		for i := 0; i < 170; i++ {
+			clause := make([]Lit, 3)
+			for j := range clause {
+				clause[j] = Lit(r.intn(40) + 1)
+				if r.float() < 0.5 {
+					clause[j] = clause[j].negation()
+				}
+			}
+			clauses = append(clauses, clause)
+		}
+		create := func() *Solver {
+			solver := CreateSolver(len(clauses), 40)
+			for _, c := range clauses {
+				solver.AddClause(append([]Lit(nil), c...))
+			}
+			return solver
+		}
+		params := SolverParams{
+			MaxConflict:         100,
+			MaxLearnts:          100,
+			VarActivityDecay:    0.95,
+			ClauseActivityDecay: 0.999,
+		}
+		expected := create().Solve(params)
+		solvers, winner, res := SolvePortfolio(create, PortfolioParams(params, 4))
+		if len(solvers) != 4 || winner < 0 || res != expected {
+			t.Fatalf("portfolio answered %v instead of %v", res, expected)
+		}
+		if res != LTRUE {
+			continue
+		}
+		model := solvers[winner].Model()
+		for _, c := range clauses {
+			satisfied := false
+			for _, l := range c {
+				satisfied = satisfied || model[l.variable()-1] == l
+			}
+			if !satisfied {
+				t.Fatalf("model falsifies %v", c)
+			}
+		}
+	}
+}
@@ -1,9 +1,9 @@
 package egosat
 
 type queue struct {
-	heap    []Lit   // Heap storage
-	indices []int   // Maps elements to their indices in the heap
-	solver  *Solver // Reference to solver for access to literal activities
+	heap     []Lit     // Heap storage
+	indices  []int     // Maps elements to their indices in the heap
+	activity []float64 // Activity of every literal, indexed by Lit.index
 }
 
 // These functions are used for computing the indices of the parent and children
@@ -12,13 +12,13 @@ func parent(idx int) int     { return (idx - 1) >> 1 }
 func leftChild(idx int) int  { return 2*idx + 1 }
 func rightChild(idx int) int { return 2*idx + 2 }
 
-// createQueue  generates a new queue for the given solver and with the given
-// capactity preallocated for the heap and the index map
-func createQueue(solver *Solver, capacity int) (q *queue) {
+// createQueue  generates a new queue ordered by the given activities and with
+// the given capactity preallocated for the heap and the index map
+func createQueue(activity []float64, capacity int) (q *queue) {
 	q = &queue{
-		heap:    make([]Lit, 0, capacity),
-		indices: make([]int, 2*capacity),
-		solver:  solver,
+		heap:     make([]Lit, 0, capacity),
+		indices:  make([]int, 2*capacity),
+		activity: activity,
 	}
 	for i := 0; i < len(q.indices); i++ {
 		q.indices[i] = -1
@@ -41,6 +41,11 @@ func (q *queue) insert(l Lit) {
 	q.moveUp(l)
 }
 
+// max returns the maximum key of the heap without removing it
+func (q *queue) max() Lit {
+	return q.heap[0]
+}
+
 // removeMax pops the maxmimum key from the heap
 func (q *queue) removeMax() Lit {
 	ret := q.heap[0]
@@ -92,5 +97,5 @@ func (q *queue) moveDown(l Lit) {
 }
 
 func (q *queue) priority(i int) float64 {
-	return q.solver.literalActivity[q.heap[i].index()]
+	return q.activity[q.heap[i].index()]
 }
@@ -0,0 +1,135 @@
+package egosat
+
+// probe runs failed literal probing at decision level 0 until the budget of
+// propagation ticks has been spent, each probe costing at least one tick.
+// Every candidate variable is probed in both polarities. The negation of a
+// literal whose propagation conflicts becomes a unit, and so do literals implied
+// by both polarities. Long clauses which propagate a literal during a probe give
+// hyper-binary resolvents, binary clauses of the negation of the probed literal
+// and the implied literal, which are learnt. Candidates are taken from where the
+// last probing phase stopped, starting with the roots of the binary implication
+// graph. The saved phases are restored afterwards. It returns false if the
+// formula was found unsatisfiable.
+func (solver *Solver) probe(budget int) bool {
+	if solver.propagate() != noClause {
+		return false
+	}
+	saved := append(solver.probePhases[:0], solver.phases...)
+	defer func() {
+		copy(solver.phases, saved)
+		solver.probePhases = saved
+	}()
+	if solver.probeMarks == nil {
+		solver.probeMarks = make([]Lbool, solver.NumVariables()+1)
+	}
+	budget += solver.stats.NumTicks
+	refilled := false
+	for spent := 0; solver.stats.NumTicks+spent < budget && solver.checkInterrupt(); spent++ {
+		if len(solver.probes) == 0 {
+			if refilled {
+				break
+			}
+			solver.probes = solver.probeCandidates(solver.probes)
+			refilled = true
+			continue
+		}
+		v := solver.probes[len(solver.probes)-1]
+		solver.probes = solver.probes[:len(solver.probes)-1]
+		if solver.decidable(v) && !solver.probeVar(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// probeCandidates returns the unassigned variables with binary implications in
+// the memory of the given slice, which is used as a stack, so that variables
+// with a literal which is a root of the binary implication graph come last.
+func (solver *Solver) probeCandidates(probes []int) []int {
+	var roots []int
+	candidates := probes[:0]
+	for v := 1; v <= solver.NumVariables(); v++ {
+		if solver.varValue(v) != LNULL {
+			continue
+		}
+		pos, neg := len(solver.binaries[Lit(v).index()]), len(solver.binaries[Lit(-v).index()])
+		if (pos > 0) != (neg > 0) {
+			roots = append(roots, v)
+		} else if pos > 0 {
+			candidates = append(candidates, v)
+		}
+	}
+	return append(candidates, roots...)
+}
+
+// probeVar probes both literals of the variable, and returns false if the
+// formula was found unsatisfiable.
+func (solver *Solver) probeVar(v int) bool {
+	pos, neg := Lit(v), Lit(-v)
+	implied, ok := solver.probeLit(pos, solver.probeLits[:0])
+	solver.probeLits = implied
+	if !ok {
+		return solver.failed(pos)
+	}
+	n := len(implied)
+	for _, l := range implied {
+		solver.probeMarks[l.variable()] = l.polarity()
+	}
+	implied, ok = solver.probeLit(neg, implied)
+	solver.probeLits = implied
+	units := solver.probeUnits[:0]
+	for _, l := range implied[n:] {
+		if solver.probeMarks[l.variable()] == l.polarity() {
+			units = append(units, l)
+		}
+	}
+	for _, l := range implied[:n] {
+		solver.probeMarks[l.variable()] = LNULL
+	}
+	solver.probeUnits = units
+	if !ok {
+		return solver.failed(neg)
+	}
+	for _, l := range units {
+		solver.stats.NumImplied++
+		solver.proofAdd(append(solver.proofLits[:0], neg, l))
+		solver.proofAdd(append(solver.proofLits[:0], pos, l))
+		solver.proofAdd(append(solver.proofLits[:0], l))
+		solver.proofDelete(append(solver.proofLits[:0], neg, l))
+		solver.proofDelete(append(solver.proofLits[:0], pos, l))
+		solver.enqueue(l, noClause)
+	}
+	return solver.propagate() == noClause
+}
+
+// probeLit assumes the literal at decision level 1 and propagates it, learning
+// hyper-binary resolvents for the literals implied by long clauses. The implied
+// literals are appended to the given slice. It returns false if the literal
+// failed.
+func (solver *Solver) probeLit(lit Lit, implied []Lit) ([]Lit, bool) {
+	solver.stats.NumProbed++
+	solver.assume(lit)
+	start := solver.trailDelim[0] + 1
+	ok := solver.propagate() == noClause
+	if ok {
+		for _, l := range solver.trail[start:] {
+			implied = append(implied, l)
+			if r := solver.reasons[l.variable()].clause; r != noClause && solver.arena.size(r) > 2 {
+				solver.stats.NumHyperBinary++
+				solver.proofAdd(append(solver.proofLits[:0], lit.negation(), l))
+				solver.addBinary(lit.negation(), l, true)
+			}
+		}
+	}
+	solver.cancelUntil(0)
+	return implied, ok
+}
+
+// failed assigns the negation of a failed literal at decision level 0 and
+// propagates it, returning false if this conflicts.
+func (solver *Solver) failed(lit Lit) bool {
+	solver.stats.NumFailed++
+	solver.proofAdd(append(solver.proofLits[:0], lit.negation()))
+	solver.enqueue(lit.negation(), noClause)
+	return solver.propagate() == noClause
+}
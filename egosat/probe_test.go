@@ -0,0 +1,52 @@
+package egosat
+
+import "testing"
+
+func TestProbeFailed(t *testing.T) {
+	solver := CreateSolver(3, 3)
+	solver.AddClause([]Lit{-1, 2})
+	solver.AddClause([]Lit{-1, 3})
+	solver.AddClause([]Lit{-2, -3})
+	if !solver.probe(100) {
+		t.Fail()
+	}
+	if solver.stats.NumFailed == 0 || solver.varValue(1) != LFALSE || solver.DecisionLevel() != 0 {
+		t.Fail()
+	}
+}
+
+func TestProbeImplied(t *testing.T) {
+	solver := CreateSolver(3, 3)
+	solver.AddClause([]Lit{-1, 2})
+	solver.AddClause([]Lit{1, 3})
+	solver.AddClause([]Lit{-3, 2})
+	solver.probeMarks = make([]Lbool, 4)
+	if !solver.probeVar(1) {
+		t.Fail()
+	}
+	if solver.stats.NumImplied == 0 || solver.varValue(2) != LTRUE || solver.varValue(1) != LNULL {
+		t.Fail()
+	}
+}
+
+func TestProbeHyperBinary(t *testing.T) {
+	solver := CreateSolver(3, 4)
+	solver.AddClause([]Lit{-1, 2})
+	solver.AddClause([]Lit{-1, 3})
+	solver.AddClause([]Lit{-2, -3, 4})
+	solver.phases[4] = LTRUE
+	if !solver.probe(100) {
+		t.Fail()
+	}
+	found := false
+	for _, l := range solver.binaries[Lit(1).index()] {
+		found = found || l == binary{4, true}
+	}
+	if !found || solver.stats.NumHyperBinary == 0 {
+		t.Fail()
+	}
+	// Probing does not change the saved phases
+	if solver.phases[4] != LTRUE || solver.phases[2] != LNULL {
+		t.Fail()
+	}
+}
@@ -0,0 +1,55 @@
+package egosat
+
+import (
+	"io"
+	"strconv"
+)
+
+// SetProof makes the solver log a DRAT proof to w: every clause it derives is
+// written as a line of literals ending with 0, and every clause it deletes as
+// the same line prefixed with "d ". If the formula is unsatisfiable, the proof
+// ends with the empty clause. Writes are not buffered, so w should be buffered
+// and flushed by the caller. It must be invoked before the first search. If
+// adding the clauses already showed the formula unsatisfiable, the proof is
+// the empty clause.
+func (solver *Solver) SetProof(w io.Writer) {
+	solver.proof = w
+	if solver.unsat {
+		solver.proofAdd(nil)
+	}
+}
+
+// proofAdd logs the addition of a clause to the proof.
+func (solver *Solver) proofAdd(lits []Lit) {
+	if solver.proof != nil {
+		solver.writeProof("", lits)
+	}
+}
+
+// proofDelete logs the deletion of a clause from the proof.
+func (solver *Solver) proofDelete(lits []Lit) {
+	if solver.proof != nil {
+		solver.writeProof("d ", lits)
+	}
+}
+
+// proofDeleteClause logs the deletion of a clause of the arena from the proof.
+func (solver *Solver) proofDeleteClause(c Clause) {
+	if solver.proof != nil {
+		solver.proofLits = solver.arena.appendLits(solver.proofLits[:0], c)
+		solver.writeProof("d ", solver.proofLits)
+	}
+}
+
+// writeProof writes a line of the proof, with the given prefix, in scratch
+// space so that logging does not allocate.
+func (solver *Solver) writeProof(prefix string, lits []Lit) {
+	buf := append(solver.proofBuf[:0], prefix...)
+	for _, l := range lits {
+		buf = strconv.AppendInt(buf, int64(l), 10)
+		buf = append(buf, ' ')
+	}
+	buf = append(buf, '0', '\n')
+	solver.proofBuf = buf
+	solver.proof.Write(buf)
+}
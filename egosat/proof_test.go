@@ -0,0 +1,151 @@
+package egosat
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWriteProof(t *testing.T) {
+	var buf bytes.Buffer
+	solver := CreateSolver(1, 3)
+	solver.SetProof(&buf)
+	solver.proofAdd([]Lit{1, -2})
+	solver.proofDelete([]Lit{3})
+	solver.proofAdd(nil)
+	if buf.String() != "1 -2 0\nd 3 0\n0\n" {
+		t.Fail()
+	}
+}
+
+func TestSearchProof(t *testing.T) {
+	var buf bytes.Buffer
+	solver := createPigeonhole(4)
+	clauses := solver.Clauses()
+	solver.SetProof(&buf)
+	params := SolverParams{
+		MaxConflict:         100,
+		MaxLearnts:          100,
+		VarActivityDecay:    0.95,
+		ClauseActivityDecay: 0.999,
+		Subsume:             true,
+		Eliminate:           true,
+	}
+	// Searching again after the answer must not log the empty clause again
+	if solver.Solve(params) != LFALSE || solver.Solve(params) != LFALSE {
+		t.FailNow()
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	empty := 0
+	for _, line := range lines {
+		if !strings.HasSuffix(line, " 0") && line != "0" {
+			t.Fail()
+		}
+		if line == "0" {
+			empty++
+		}
+	}
+	if empty != 1 || lines[len(lines)-1] != "0" {
+		t.Fail()
+	}
+	if !checkRUP(clauses, lines) {
+		t.Fail()
+	}
+}
+
+func TestProofUnsatisfiableClauses(t *testing.T) {
+	var buf bytes.Buffer
+	solver := CreateSolver(2, 1)
+	solver.AddClause([]Lit{1})
+	if solver.AddClause([]Lit{-1}) != ErrUnsatisfiable {
+		t.FailNow()
+	}
+	solver.SetProof(&buf)
+	if solver.Search(SolverParams{}) != LFALSE || buf.String() != "0\n" {
+		t.Fail()
+	}
+}
+
+// checkRUP reports whether every clause added by the proof lines is implied by
+// unit propagation from the clauses and the clauses added before it and not
+// deleted, and whether the proof derives the empty clause.
+func checkRUP(clauses [][]Lit, lines []string) bool {
+	key := func(lits []Lit) string {
+		sorted := append([]Lit(nil), lits...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		return fmt.Sprint(sorted)
+	}
+	active := make(map[string][][]Lit)
+	for _, c := range clauses {
+		active[key(c)] = append(active[key(c)], c)
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		deletion := fields[0] == "d"
+		if deletion {
+			fields = fields[1:]
+		}
+		var lits []Lit
+		for _, f := range fields[:len(fields)-1] {
+			l, _ := strconv.Atoi(f)
+			lits = append(lits, Lit(l))
+		}
+		k := key(lits)
+		if deletion {
+			if len(active[k]) == 0 {
+				return false
+			}
+			active[k] = active[k][1:]
+			continue
+		}
+		if !propagatesConflict(active, lits) {
+			return false
+		}
+		if len(lits) == 0 {
+			return true
+		}
+		active[k] = append(active[k], lits)
+	}
+	return false
+}
+
+// propagatesConflict reports whether unit propagation over the active clauses
+// after assigning the negations of the literals leads to a conflict.
+func propagatesConflict(active map[string][][]Lit, lits []Lit) bool {
+	value := make(map[Lit]bool)
+	for _, l := range lits {
+		value[l.negation()] = true
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, cs := range active {
+			for _, c := range cs {
+				var unit Lit
+				free := 0
+				satisfied := false
+				for _, l := range c {
+					if value[l] {
+						satisfied = true
+						break
+					}
+					if !value[l.negation()] {
+						unit = l
+						free++
+					}
+				}
+				switch {
+				case satisfied || free > 1:
+				case free == 0:
+					return true
+				default:
+					value[unit] = true
+					changed = true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package egosat
+
+// The random struct is a small xorshift64* pseudo random number generator. The
+// solver uses its own generator rather than math/rand so that runs with the same
+// seed are bit-identical on every platform and Go version.
+type random struct {
+	state uint64 // Current state, never zero
+}
+
+// createRandom creates a new generator from the given seed. The seed is mixed
+// with the splitmix64 finalizer so that similar seeds give unrelated sequences.
+func createRandom(seed uint64) *random {
+	z := seed + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	if z == 0 {
+		z = 0x9e3779b97f4a7c15
+	}
+	return &random{state: z}
+}
+
+// next returns the next 64 bit pseudo random number.
+func (r *random) next() uint64 {
+	r.state ^= r.state >> 12
+	r.state ^= r.state << 25
+	r.state ^= r.state >> 27
+	return r.state * 0x2545f4914f6cdd1d
+}
+
+// float returns a pseudo random number in [0, 1).
+func (r *random) float() float64 {
+	return float64(r.next()>>11) / (1 << 53)
+}
+
+// intn returns a pseudo random number in [0, n).
+func (r *random) intn(n int) int {
+	return int(r.next() % uint64(n))
+}
+
+// shuffle permutes the given variables uniformly at random.
+func (r *random) shuffle(vars []int) {
+	for i := len(vars) - 1; i > 0; i-- {
+		j := r.intn(i + 1)
+		vars[i], vars[j] = vars[j], vars[i]
+	}
+}
@@ -0,0 +1,32 @@
+package egosat
+
+import "testing"
+
+func TestRandom(t *testing.T) {
+	r1, r2 := createRandom(42), createRandom(42)
+	for i := 0; i < 100; i++ {
+		if r1.next() != r2.next() {
+			t.Fail()
+		}
+	}
+	if createRandom(1).next() == createRandom(2).next() {
+		t.Fail()
+	}
+	for i := 0; i < 100; i++ {
+		if f := r1.float(); f < 0 || f >= 1 {
+			t.Fail()
+		}
+		if n := r1.intn(7); n < 0 || n >= 7 {
+			t.Fail()
+		}
+	}
+	vars := []int{1, 2, 3, 4, 5}
+	r1.shuffle(vars)
+	seen := make(map[int]bool)
+	for _, v := range vars {
+		seen[v] = true
+	}
+	if len(seen) != 5 {
+		t.Fail()
+	}
+}
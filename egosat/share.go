@@ -0,0 +1,175 @@
+package egosat
+
+// These constants control the exchange of clauses between workers.
+const (
+	shareClauseBuffer = 1 << 12 // Clauses a worker may have waiting to be imported
+	shareUnitBuffer   = 1 << 14 // Units a worker may have waiting to be imported
+)
+
+// The sharedClause struct holds a learnt clause exported to other workers.
+type sharedClause struct {
+	lits []Lit // Literals of the clause, owned by the receiving worker
+	lbd  int   // LBD of the clause in the exporting worker
+}
+
+// The sharer struct connects a worker of a portfolio to the other workers.
+// The buffers of a worker are channels which only the worker itself drains,
+// at decision level 0 when a search starts, so that imported clauses reach its
+// clause database and watcher lists from its own goroutine only. Exports never
+// block: a clause or unit is dropped if the buffer of a worker is full.
+type sharer struct {
+	clauses  chan sharedClause // Clauses exported by the other workers
+	units    chan Lit          // Units exported by the other workers
+	peers    []*sharer         // Buffers of the other workers
+	exported int               // Level 0 assignments of the trail already exported, -1 before the first search
+	lits     []Lit             // Scratch space for the literals of an imported clause
+}
+
+// connect makes the solvers exchange clauses during their searches.
+func connect(solvers []*Solver) {
+	sharers := make([]*sharer, len(solvers))
+	for i := range sharers {
+		sharers[i] = &sharer{
+			clauses:  make(chan sharedClause, shareClauseBuffer),
+			units:    make(chan Lit, shareUnitBuffer),
+			exported: -1,
+		}
+	}
+	for i, solver := range solvers {
+		for j, s := range sharers {
+			if j != i {
+				sharers[i].peers = append(sharers[i].peers, s)
+			}
+		}
+		solver.share = sharers[i]
+	}
+}
+
+// sharing reports whether the solver exchanges clauses with other workers.
+func (solver *Solver) sharing() bool {
+	return solver.share != nil && solver.params.ShareSize > 0
+}
+
+// exportClause sends a copy of a learnt clause to the other workers if it is
+// at most ShareSize literals long, its LBD is at most ShareLBD, and it only
+// contains variables the other workers share.
+func (solver *Solver) exportClause(lits []Lit, lbd int) {
+	if !solver.sharing() || len(lits) < 2 || len(lits) > solver.params.ShareSize || lbd > solver.params.ShareLBD {
+		return
+	}
+	for _, l := range lits {
+		if !solver.shared(l.variable()) {
+			return
+		}
+	}
+	for _, peer := range solver.share.peers {
+		c := sharedClause{lits: append([]Lit(nil), lits...), lbd: lbd}
+		select {
+		case peer.clauses <- c:
+			solver.stats.NumExported++
+		default:
+			solver.stats.NumShareDropped++
+		}
+	}
+}
+
+// shared reports whether the variable means the same to the other workers.
+// Variables added by the solver are unknown to them, and eliminated or
+// substituted variables get their values from the model extension of this
+// worker only.
+func (solver *Solver) shared(v int) bool {
+	return !solver.hidden[v] && !solver.eliminated[v]
+}
+
+// exportUnits sends the level 0 assignments made since the last export to the
+// other workers. Units are exported whatever the limits of the clauses, as
+// they simplify the formula of every worker. The assignments made before the
+// first search follow from the formula and its preprocessing, which the other
+// workers share, so they are not exported.
+func (solver *Solver) exportUnits() {
+	end := len(solver.trail)
+	if solver.DecisionLevel() > 0 {
+		end = solver.trailDelim[0]
+	}
+	if solver.share.exported < 0 {
+		solver.share.exported = end
+	}
+	for _, l := range solver.trail[solver.share.exported:end] {
+		if !solver.shared(l.variable()) {
+			continue
+		}
+		for _, peer := range solver.share.peers {
+			select {
+			case peer.units <- l:
+				solver.stats.NumUnitsExported++
+			default:
+				solver.stats.NumShareDropped++
+			}
+		}
+	}
+	solver.share.exported = end
+}
+
+// importShared backtracks to decision level 0 if other workers exported
+// clauses or units, exports the new units of the solver, and adds the clauses
+// and units of the other workers, units first. The clauses are implied by the
+// formula, but they may contain variables which this solver eliminated or
+// substituted, in which case they are ignored. It returns false if the formula
+// was found unsatisfiable.
+func (solver *Solver) importShared() bool {
+	if !solver.sharing() {
+		return true
+	}
+	pending := len(solver.share.units) > 0 || len(solver.share.clauses) > 0
+	if pending {
+		solver.cancelUntil(0)
+	}
+	solver.exportUnits()
+	if !pending {
+		return true
+	}
+	for n := len(solver.share.units); n > 0; n-- {
+		l := <-solver.share.units
+		if solver.eliminated[l.variable()] || solver.litValue(l) == LTRUE {
+			continue
+		}
+		solver.stats.NumUnitsImported++
+		if !solver.enqueue(l, noClause) {
+			return false
+		}
+	}
+	// The imported units are known to the other workers already
+	solver.share.exported = len(solver.trail)
+	for n := len(solver.share.clauses); n > 0; n-- {
+		if !solver.importClause(<-solver.share.clauses) {
+			return false
+		}
+	}
+	return true
+}
+
+// importClause adds a clause exported by another worker as a learnt clause,
+// without its literals false at decision level 0, unless one of its literals
+// is true or it contains an eliminated variable. It returns false if all
+// literals of the clause are false.
+func (solver *Solver) importClause(c sharedClause) bool {
+	lits := solver.share.lits[:0]
+	for _, l := range c.lits {
+		if solver.eliminated[l.variable()] {
+			return true
+		}
+		switch solver.litValue(l) {
+		case LTRUE:
+			return true
+		case LNULL:
+			lits = append(lits, l)
+		}
+	}
+	solver.share.lits = lits
+	solver.stats.NumImported++
+	ok, clause := solver.attach(lits, true)
+	if clause != noClause {
+		solver.arena.setLBD(clause, c.lbd)
+	}
+	return ok
+}
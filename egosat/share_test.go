@@ -0,0 +1,188 @@
+package egosat
+
+import "testing"
+
+func TestShareClause(t *testing.T) {
+	solvers := []*Solver{CreateSolver(1, 5), CreateSolver(1, 5)}
+	for _, solver := range solvers {
+		solver.AddClause([]Lit{1, 2, 3, 4})
+		solver.configure(SolverParams{ShareSize: 3, ShareLBD: 2})
+	}
+	connect(solvers)
+	a, b := solvers[0], solvers[1]
+	a.exportClause([]Lit{1, 2, 3, 4}, 2)
+	a.exportClause([]Lit{1, 2, 5}, 3)
+	a.exportClause([]Lit{-1, 2, 5}, 2)
+	b.exportClause([]Lit{-1, 3}, 1)
+	if a.stats.NumExported != 1 || b.stats.NumExported != 1 {
+		t.FailNow()
+	}
+	if !b.importShared() || b.stats.NumImported != 1 {
+		t.Fail()
+	}
+	b.enqueue(5, noClause)
+	if !b.importShared() || b.stats.NumUnitsExported != 1 {
+		t.Fail()
+	}
+	if !a.importShared() || a.stats.NumUnitsImported != 1 || a.varValue(5) != LTRUE {
+		t.Fail()
+	}
+	if a.stats.NumImported != 1 || len(a.binaries[Lit(1).index()]) != 1 {
+		t.Fail()
+	}
+}
+
+func TestShareEliminated(t *testing.T) {
+	solvers := []*Solver{CreateSolver(1, 3), CreateSolver(1, 3)}
+	for _, solver := range solvers {
+		solver.configure(SolverParams{ShareSize: 3, ShareLBD: 2})
+	}
+	connect(solvers)
+	solvers[1].eliminated[3] = true
+	solvers[0].exportClause([]Lit{1, 2, -3}, 2)
+	solvers[0].exportClause([]Lit{-1, -2}, 2)
+	if !solvers[1].importShared() || solvers[1].stats.NumImported != 1 {
+		t.Fail()
+	}
+}
+
+func TestShareEliminatedUnits(t *testing.T) {
+	solvers := []*Solver{CreateSolver(4, 4), CreateSolver(4, 4)}
+	for _, solver := range solvers {
+		solver.AddClause([]Lit{-4, 1})
+		solver.AddClause([]Lit{-4, 2})
+		solver.AddClause([]Lit{4, -1, -2})
+		solver.AddClause([]Lit{4, 3})
+		solver.configure(SolverParams{ShareSize: 3, ShareLBD: 2})
+	}
+	connect(solvers)
+	a := solvers[0]
+	a.importShared()
+	if !a.eliminate(elimBudget) || a.stats.NumEliminated == 0 || !a.importShared() {
+		t.FailNow()
+	}
+	if a.stats.NumUnitsExported != 0 || len(solvers[1].share.units) != 0 {
+		t.Fail()
+	}
+}
+
+func TestSharePortfolio(t *testing.T) {
+	r := createRandom(19)
+	exported := 0
+	for n := 0; n < 10; n++ {
+		var clauses [][]Lit
+		for i := 0; i < 400; i++ {
+			clause := make([]Lit, 3)
+			for j := range clause {
+				clause[j] = Lit(r.intn(95) + 1)
+				if r.float() < 0.5 {
+					clause[j] = clause[j].negation()
+				}
+			}
+			clauses = append(clauses, clause)
+		}
+		create := func() *Solver {
+			solver := CreateSolver(len(clauses), 95)
+			for _, c := range clauses {
+				solver.AddClause(append([]Lit(nil), c...))
+			}
+			return solver
+		}
+		params := SolverParams{
+			MaxConflict:         20,
+			MaxLearnts:          100,
+			VarActivityDecay:    0.95,
+			ClauseActivityDecay: 0.999,
+			Eliminate:           true,
+			ShareSize:           8,
+			ShareLBD:            4,
+		}
+		expected := create().Solve(params)
+		solvers, winner, res := SolvePortfolio(create, PortfolioParams(params, 4))
+		if winner < 0 || res != expected {
+			t.Fatalf("portfolio answered %v instead of %v", res, expected)
+		}
+		for _, solver := range solvers {
+			exported += solver.Stats().NumExported
+		}
+		if res != LTRUE {
+			continue
+		}
+		model := solvers[winner].Model()
+		for _, c := range clauses {
+			satisfied := false
+			for _, l := range c {
+				satisfied = satisfied || model[l.variable()-1] == l
+			}
+			if !satisfied {
+				t.Fatalf("model falsifies %v", c)
+			}
+		}
+	}
+	if exported == 0 {
+		t.Fail()
+	}
+}
+
+func TestSharePortfolioModel(t *testing.T) {
+	r := createRandom(23)
+	eliminated, satisfiable := 0, 0
+	for n := 0; n < 10; n++ {
+		var clauses [][]Lit
+		for i := 0; i < 500; i++ {
+			clause := make([]Lit, 3)
+			for j := range clause {
+				clause[j] = Lit(r.intn(120) + 1)
+				if r.float() < 0.5 {
+					clause[j] = clause[j].negation()
+				}
+			}
+			clauses = append(clauses, clause)
+		}
+		create := func() *Solver {
+			solver := CreateSolver(len(clauses), 120)
+			for _, c := range clauses {
+				solver.AddClause(append([]Lit(nil), c...))
+			}
+			// Elimination is due at every restart
+			for i := range solver.schedules {
+				solver.schedules[i] = schedule{interval: 1}
+			}
+			return solver
+		}
+		params := SolverParams{
+			MaxConflict:         10,
+			MaxLearnts:          100,
+			VarActivityDecay:    0.95,
+			ClauseActivityDecay: 0.999,
+			Eliminate:           true,
+			ShareSize:           8,
+			ShareLBD:            4,
+		}
+		expected := create().Solve(params)
+		solvers, winner, res := SolvePortfolio(create, PortfolioParams(params, 2))
+		if winner < 0 || res != expected {
+			t.Fatalf("portfolio answered %v instead of %v", res, expected)
+		}
+		for _, solver := range solvers {
+			eliminated += solver.Stats().NumEliminated
+		}
+		if res != LTRUE {
+			continue
+		}
+		satisfiable++
+		model := solvers[winner].Model()
+		for _, c := range clauses {
+			satisfied := false
+			for _, l := range c {
+				satisfied = satisfied || model[l.variable()-1] == l
+			}
+			if !satisfied {
+				t.Fatalf("model falsifies %v", c)
+			}
+		}
+	}
+	if eliminated == 0 || satisfiable == 0 {
+		t.Fail()
+	}
+}
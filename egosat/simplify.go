@@ -0,0 +1,67 @@
+package egosat
+
+// Simplify runs the preprocessing passes enabled by the parameters, followed
+// by failed literal probing if ProbeBudget is set, without searching. It returns
+// LFALSE if the formula was found unsatisfiable and LNULL otherwise. The
+// simplified formula is given by Clauses, and the clauses needed to extend its
+// models to the removed variables by Extension. Clauses cannot be added
+// afterwards.
+func (solver *Solver) Simplify(params SolverParams) Lbool {
+	solver.configure(params)
+	if solver.unsat {
+		return solver.unsatisfiable()
+	}
+	solver.cancelUntil(0)
+	if !solver.preprocessed {
+		solver.preprocessed = true
+		if !solver.preprocess() {
+			return solver.unsatisfiable()
+		}
+	}
+	if params.ProbeBudget > 0 && !solver.probe(params.ProbeBudget) {
+		return solver.unsatisfiable()
+	}
+	if solver.propagate() != noClause {
+		return solver.unsatisfiable()
+	}
+	return LNULL
+}
+
+// Clauses returns the irredundant clauses of the formula at decision level 0,
+// without satisfied clauses and false literals, and the unit clauses of the
+// variables assigned at level 0 which have not been eliminated.
+func (solver *Solver) Clauses() [][]Lit {
+	var clauses [][]Lit
+	for _, l := range solver.trail {
+		if solver.level[l.variable()] == 0 && !solver.eliminated[l.variable()] {
+			clauses = append(clauses, []Lit{l})
+		}
+	}
+	for _, c := range solver.irredundant() {
+		j := 0
+		satisfied := false
+		for _, l := range c {
+			switch {
+			case solver.level[l.variable()] != 0 || solver.litValue(l) == LNULL:
+				c[j] = l
+				j++
+			case solver.litValue(l) == LTRUE:
+				satisfied = true
+			}
+		}
+		if !satisfied {
+			clauses = append(clauses, c[:j])
+		}
+	}
+	return clauses
+}
+
+// Extension returns a copy of the clauses removed by simplification, each with
+// the literal flipped when extending a model first, as expected by ExtendModel.
+func (solver *Solver) Extension() [][]Lit {
+	extension := make([][]Lit, len(solver.extension))
+	for i, c := range solver.extension {
+		extension[i] = append([]Lit(nil), c...)
+	}
+	return extension
+}
@@ -0,0 +1,58 @@
+package egosat
+
+import "testing"
+
+func TestSimplifyExtend(t *testing.T) {
+	r := createRandom(7)
+	params := SolverParams{
+		MaxConflict:         1000,
+		MaxLearnts:          100,
+		VarActivityDecay:    0.95,
+		ClauseActivityDecay: 0.999,
+	}
+	for n := 0; n < 20; n++ {
+		var clauses [][]Lit
+		solver := CreateSolver(150, 40)
+		for i := 0; i < 150; i++ {
+			clause := make([]Lit, 2+r.intn(2))
+			for j := range clause {
+				clause[j] = Lit(r.intn(40) + 1)
+				if r.float() < 0.5 {
+					clause[j] = clause[j].negation()
+				}
+			}
+			clauses = append(clauses, clause)
+			solver.AddClause(append([]Lit(nil), clause...))
+		}
+		simplify := params
+		simplify.Substitute, simplify.Subsume, simplify.Block, simplify.Eliminate = true, true, true, true
+		if solver.Simplify(simplify) == LFALSE {
+			continue
+		}
+		if solver.AddClause([]Lit{1, 2}) != ErrPreprocessed {
+			t.Fail()
+		}
+		simplified := CreateSolver(150, 40)
+		for _, c := range solver.Clauses() {
+			simplified.AddClause(c)
+		}
+		res := simplified.Search(params)
+		for res == LNULL {
+			res = simplified.Search(params)
+		}
+		if res != LTRUE {
+			continue
+		}
+		model := append([]Lit(nil), simplified.Model()...)
+		ExtendModel(model, solver.Extension())
+		for _, c := range clauses {
+			satisfied := false
+			for _, l := range c {
+				satisfied = satisfied || model[l.variable()-1] == l
+			}
+			if !satisfied {
+				t.Fatalf("extended model falsifies %v", c)
+			}
+		}
+	}
+}
@@ -1,44 +1,175 @@
 package egosat
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"time"
 )
 
 // The SolverParams struct stores the solver parameters pertaining to search.
 type SolverParams struct {
-	MaxConflict         int     // Number of conflicts before restart is required
-	MaxLearnts          int     // Maximum number of learnt clauses to store at one time
-	VarActivityDecay    float64 // Decay factor for variable activities
-	ClauseActivityDecay float64 // Decay factor for clause activities
+	MaxConflict         int       // Number of conflicts before restart is required
+	MaxLearnts          int       // Maximum number of learnt clauses to store at one time
+	VarActivityDecay    float64   // Decay factor for variable activities
+	ClauseActivityDecay float64   // Decay factor for clause activities
+	Heuristic           Heuristic // Decision heuristic used to select branching literals
+	Seed                uint64    // Seed of the pseudo random number generator
+	RandomFreq          float64   // Probability of a random decision
+	RandomInit          bool      // Start with small random activities and random phases
+	Shuffle             bool      // Randomly shuffle the initial order of the variables
+	Stable              bool      // Search in stable mode, steering decisions toward target phases
+	RephaseInterval     int       // Base number of conflicts between rephases, 0 disables rephasing
+	ChronoBacktrack     int       // Backjumps longer than this backtrack one level, 0 disables
+	ModeInterval        int       // Conflicts before the first mode switch, 0 disables switching
+	StableHeuristic     Heuristic // Decision heuristic used in stable mode when switching modes
+	LubyUnit            int       // Conflicts per unit of the Luby restart sequence in stable mode
+	ReuseTrail          bool      // Restarts keep the decisions which would be taken again
+	MemoryLimit         int       // Estimated bytes the solver may use, 0 disables the limit
+	Eliminate           bool      // Run bounded variable elimination before the first search and between restarts
+	Subsume             bool      // Remove subsumed clauses and strengthen clauses by resolution
+	ProbeBudget         int       // Least propagation ticks spent by a probing pass, 0 disables probing
+	Substitute          bool      // Replace equivalent literals found in binary clauses by one of them
+	Block               bool      // Remove blocked clauses before the first search
+	Cover               bool      // Also remove covered clauses when removing blocked clauses
+	Vivify              bool      // Periodically vivify the core and tier2 learnt clauses
+	AddVariables        bool      // Replace repeated clause patterns by fresh variables before the first search
+	Unhide              bool      // Remove hidden tautologies and hidden literals found by stamping binary implications
+	ShareSize           int       // Longest learnt clause exported to the other workers of a portfolio, 0 disables sharing
+	ShareLBD            int       // Largest LBD of a learnt clause exported to the other workers of a portfolio
 }
 
 // The SolverStats struct is used to store statistics about the search process
 type SolverStats struct {
-	NumConflicts   int // Number of conflicts encountered
-	NumRestarts    int // Number of restarts
-	NumAssumptions int // Number of branching decisions made
-	NumLearntUnit  int // Number of learnt unit clauses
+	NumConflicts         int       // Number of conflicts encountered
+	NumRestarts          int       // Number of restarts
+	NumAssumptions       int       // Number of branching decisions made
+	NumLearntUnit        int       // Number of learnt unit clauses
+	NumRandom            int       // Number of random branching decisions
+	NumRephases          int       // Number of times the saved phases were reset
+	NumChrono            int       // Number of chronological backtracks
+	NumTicks             int       // Number of watched clauses visited during propagation
+	NumModeSwitch        int       // Number of switches between focused and stable mode
+	NumReuses            int       // Number of restarts which kept part of the trail
+	NumReusedLevel       int       // Number of decision levels kept by restarts
+	NumCollections       int       // Number of garbage collections of the clause arena
+	NumMemoryReduce      int       // Number of learnt clause reductions forced by the memory limit
+	NumEliminated        int       // Number of variables removed by bounded variable elimination
+	NumGates             int       // Number of eliminations which resolved only against a gate
+	NumSubsumed          int       // Number of clauses removed by subsumption
+	NumStrengthened      int       // Number of literals removed by self-subsuming resolution
+	NumProbed            int       // Number of literals probed
+	NumFailed            int       // Number of failed literals found by probing
+	NumImplied           int       // Number of units implied by both literals of a probed variable
+	NumHyperBinary       int       // Number of hyper-binary resolvents learnt by probing
+	NumSubstituted       int       // Number of variables replaced by an equivalent literal
+	NumReduced           int       // Number of binary clauses removed by transitive reduction
+	NumBlocked           int       // Number of blocked clauses removed
+	NumCovered           int       // Number of covered clauses removed
+	NumVivified          int       // Number of learnt clauses vivified
+	NumVivifyShortened   int       // Number of learnt clauses shortened by vivification
+	NumVivifyRemoved     int       // Number of learnt clauses found implied by vivification
+	NumAdded             int       // Number of variables added by bounded variable addition
+	NumAddSaved          int       // Number of clauses saved by bounded variable addition
+	NumHiddenTautologies int       // Number of hidden tautologies removed by unhiding
+	NumHiddenLiterals    int       // Number of hidden literals removed by unhiding
+	NumHiddenFailed      int       // Number of failed literals found by unhiding
+	NumExported          int       // Number of learnt clauses exported to other workers
+	NumImported          int       // Number of clauses imported from other workers
+	NumUnitsExported     int       // Number of units exported to other workers
+	NumUnitsImported     int       // Number of units imported from other workers
+	NumShareDropped      int       // Number of clauses and units dropped because a worker was not importing
+	MemoryUsage          int       // Estimated bytes used by clauses, watches and per variable arrays
+	Probing              PassStats // Passes of failed literal probing run by the inprocessing scheduler
+	Unhiding             PassStats // Passes of unhiding run by the inprocessing scheduler
+	Vivification         PassStats // Passes of vivification run by the inprocessing scheduler
+	Subsumption          PassStats // Passes of subsumption run by the inprocessing scheduler
+	Elimination          PassStats // Passes of variable elimination run by the inprocessing scheduler
+	Substitution         PassStats // Passes of equivalent literal substitution run by the inprocessing scheduler
+}
+
+// The PassStats struct records the cost and the effect of the passes of an
+// inprocessing technique.
+type PassStats struct {
+	Passes int           // Number of passes run
+	Time   time.Duration // Time spent by the passes
+	Effect int           // Number of simplifications made by the passes
 }
 
 // The Solver struct contains the formula as well as the state of the solver
 // over the course of solving the formulae.
 type Solver struct {
-	clauses             []*Clause   // References to all clauses of original forumla
-	learntClauses       []*Clause   // References to all learnt clauses
-	clauseActivityInc   float64     // Increment value for clause activities
-	clauseActivityDecay float64     // Decay rate for clause activity increment
-	varActivityInc      float64     // Increment value for variable activities
-	varActivityDecay    float64     // Decay rate for variable activity increment
-	literalActivity     []float64   // Slice storing activity of every literal
-	variableOrder       *queue      // Dynamic priority queue for branch variable selection
-	watcherLists        [][]*Clause // Clauses watching each literal
-	propQueue           []Lit       // FIFO queue of unit literals for propagation
-	assignments         []Lbool     // Slice storing variable assignments
-	trail               []Lit       // Variable assignment stack
-	trailDelim          []int       // Indices separating decision levels in the trail
-	reasons             []*Clause   // Antecedent clause for assignment variables
-	level               []int       // Decision level of each variable
-	stats               SolverStats // Runtime statistics
+	arena               clauseArena  // Storage of all clauses with at least three literals
+	spare               []uint32     // Memory of the previous arena, reused by garbage collection
+	clauses             []Clause     // References to all clauses of original forumla
+	learntClauses       []Clause     // References to all learnt clauses
+	clauseActivityInc   float64      // Increment value for clause activities
+	clauseActivityDecay float64      // Decay rate for clause activity increment
+	varActivityDecay    float64      // Decay rate for variable activity increment
+	brancher            brancher     // Decision heuristic selecting branch literals
+	branchers           [2]brancher  // Decision heuristics of focused and stable mode
+	heuristics          [2]Heuristic // Identifies the heuristics implemented by branchers
+	stable              bool         // Indicates whether the solver is in stable mode
+	modeConflicts       int          // Number of conflicts when the current mode started
+	modeTicks           int          // Number of ticks when the current mode started
+	modeLimit           int          // Ticks budget of the current mode, 0 during the first mode
+	lubyIndex           int          // Index of the next restart in the Luby sequence
+	lbdFast             ema          // Fast moving average of learnt clause LBDs
+	lbdSlow             ema          // Slow moving average of learnt clause LBDs
+	levelStamps         []int        // Marks decision levels while computing LBDs
+	lbdStamp            int          // Current mark for levelStamps
+	phases              []Lbool      // Last value assigned to each variable
+	target              []Lbool      // Phases of the longest conflict-free trail since the last rephase
+	targetAssigned      int          // Length of the trail which gave the target phases
+	best                []Lbool      // Phases of the longest conflict-free trail
+	bestAssigned        int          // Length of the trail which gave the best phases
+	rephaseLimit        int          // Number of conflicts at which the next rephase is due
+	varOrder            []int        // Order in which variables are given to branchers
+	params              SolverParams // Parameters of the current search
+	rng                 *random      // Pseudo random number generator, seeded by Search
+	watcherLists        [][]watcher  // Clauses watching each literal
+	binaries            [][]binary   // Literals implied by each literal through binary clauses
+	numBinaries         int          // Number of irredundant binary clauses
+	binaryConflict      Clause       // Scratch clause returned for conflicting binary clauses
+	binaryReason        Clause       // Scratch clause standing for binary reasons in analysis
+	qhead               int          // Index of the first trail literal which is not yet propagated
+	undone              []Lit        // Scratch space for literals unassigned by cancelUntil
+	assignments         []Lbool      // Slice storing variable assignments
+	trail               []Lit        // Variable assignment stack
+	trailDelim          []int        // Indices separating decision levels in the trail
+	reasons             []reason     // Antecedent of every assigned variable
+	seen                []bool       // Marks variables visited by conflict analysis
+	analyzed            []int        // Variables marked in seen, to be cleared after analysis
+	learnt              []Lit        // Scratch space for the learnt clause of analysis
+	reasonLits          []Lit        // Scratch space for the reason of a literal in analysis
+	level               []int        // Decision level of each variable
+	stats               SolverStats  // Runtime statistics
+	memoryCheck         int          // Number of conflicts at which the memory usage is next estimated
+	err                 error        // Reason why the last search gave up, nil if none
+	interrupted         int32        // Set to 1 by Interrupt, accessed atomically
+	share               *sharer      // Buffers exchanging clauses with other workers, nil if none
+	preprocessed        bool         // Indicates whether preprocessing has been run
+	unsat               bool         // Indicates whether a conflict was found at decision level 0
+	addMarks            []Lbool      // Polarity of each variable in the clause being added
+	addLits             []Lit        // Scratch space for the normalized clause being added
+	simplified          int          // Number of level 0 assignments when the clauses were last simplified
+	schedules           []schedule   // Next pass of each inprocessing technique
+	inprocessTicks      int          // Propagation ticks spent by inprocessing passes
+	vivifyClauses       []Clause     // Scratch space for the clauses to vivify
+	vivifyLits          []Lit        // Scratch space for the literals of a vivified clause
+	eliminated          []bool       // Marks variables removed by variable elimination
+	numEliminated       int          // Number of variables marked in eliminated
+	hidden              []bool       // Marks variables added by the solver, left out of the model
+	extension           [][]Lit      // Clauses removed by elimination, eliminated literal first
+	model               []Lit        // Satisfying assignment found by the last search
+	proof               io.Writer    // Destination of the DRAT proof, nil if none is logged
+	proofBuf            []byte       // Scratch space for a line of the proof
+	proofLits           []Lit        // Scratch space for the literals of a line of the proof
+	probes              []int        // Variables left to probe, as a stack
+	probeLits           []Lit        // Literals implied by the probes of a variable
+	probeUnits          []Lit        // Scratch space for literals implied by both polarities
+	probeMarks          []Lbool      // Values implied by the first polarity of a probed variable
+	probePhases         []Lbool      // Saved phases restored after probing
 }
 
 // CreateSolver creates a new Solver for a formulae with the given number of
@@ -47,135 +178,407 @@ type Solver struct {
 // structures.
 func CreateSolver(nClauses, nVars int) *Solver {
 	solver := &Solver{
-		clauses:           make([]*Clause, 0, nClauses),
-		learntClauses:     make([]*Clause, 0, 100),
-		watcherLists:      make([][]*Clause, 2*nVars),
+		arena:             createArena(4 * nClauses),
+		clauses:           make([]Clause, 0, nClauses),
+		learntClauses:     make([]Clause, 0, 100),
+		watcherLists:      make([][]watcher, 2*nVars),
+		binaries:          make([][]binary, 2*nVars),
 		assignments:       make([]Lbool, nVars+1),
 		trail:             make([]Lit, 0, nVars),
-		reasons:           make([]*Clause, nVars+1),
+		reasons:           make([]reason, nVars+1),
+		seen:              make([]bool, nVars+1),
+		addMarks:          make([]Lbool, nVars+1),
 		level:             make([]int, nVars+1),
-		literalActivity:   make([]float64, 2*nVars),
-		varActivityInc:    1,
+		phases:            make([]Lbool, nVars+1),
+		target:            make([]Lbool, nVars+1),
+		best:              make([]Lbool, nVars+1),
+		varOrder:          make([]int, nVars),
+		levelStamps:       make([]int, nVars+1),
+		eliminated:        make([]bool, nVars+1),
+		hidden:            make([]bool, nVars+1),
+		lbdFast:           createEMA(lbdFastAlpha),
+		lbdSlow:           createEMA(lbdSlowAlpha),
 		clauseActivityInc: 1,
 		stats:             SolverStats{NumRestarts: -1},
+		schedules:         createSchedules(),
 	}
-	solver.variableOrder = createQueue(solver, nVars)
-	for i := 1; i <= nVars; i++ {
-		solver.literalActivity[Lit(i).index()] = 0.
-		solver.literalActivity[Lit(-i).index()] = 0.
-		solver.variableOrder.insert(Lit(i))
-		solver.variableOrder.insert(Lit(-i))
+	for i := range solver.varOrder {
+		solver.varOrder[i] = i + 1
 	}
+	solver.useHeuristic(VSIDS)
+	solver.binaryConflict = solver.arena.alloc([]Lit{0, 0}, false)
+	solver.binaryReason = solver.arena.alloc([]Lit{0, 0}, false)
 	return solver
 }
 
-// AddClause adds a clause to the Solver. The provided literals make up the
-// clause and the learnt flag indicates whether the clause is learnt, i.e.
-// deduced from the original formula, or part of the original formula. In
-// general, the case learnt=true should only be used by the internals of the
-// solver.
-func (solver *Solver) AddClause(lits []Lit, learnt bool) (bool, *Clause) {
-	if !learnt {
-		seen := make(map[Lit]bool)
-		for _, l := range lits {
-			if solver.litValue(l) == LTRUE {
-				return true, nil
-			}
-			if _, ok := seen[l.negation()]; ok {
-				return true, nil
-			}
-			seen[l] = true
+// NewVar adds a variable to the solver and returns it. The decision heuristics
+// are created again for the new number of variables, which resets their scores.
+func (solver *Solver) NewVar() int {
+	v := solver.newVar(false)
+	solver.resetBranchers()
+	return v
+}
+
+// newVar adds a variable, which is left out of the model if it is hidden. The
+// decision heuristics do not know the variable until resetBranchers is invoked,
+// which must happen before the next decision or backtrack.
+func (solver *Solver) newVar(hidden bool) int {
+	v := len(solver.assignments)
+	solver.watcherLists = append(solver.watcherLists, nil, nil)
+	solver.binaries = append(solver.binaries, nil, nil)
+	solver.assignments = append(solver.assignments, LNULL)
+	solver.reasons = append(solver.reasons, reason{})
+	solver.seen = append(solver.seen, false)
+	solver.addMarks = append(solver.addMarks, LNULL)
+	solver.level = append(solver.level, 0)
+	solver.phases = append(solver.phases, LNULL)
+	solver.target = append(solver.target, LNULL)
+	solver.best = append(solver.best, LNULL)
+	solver.varOrder = append(solver.varOrder, v)
+	solver.levelStamps = append(solver.levelStamps, 0)
+	solver.eliminated = append(solver.eliminated, false)
+	solver.hidden = append(solver.hidden, hidden)
+	if solver.probeMarks != nil {
+		solver.probeMarks = append(solver.probeMarks, LNULL)
+	}
+	return v
+}
+
+// resetBranchers creates the decision heuristics again for the current number
+// of variables, which resets their scores.
+func (solver *Solver) resetBranchers() {
+	solver.branchers = [2]brancher{}
+	solver.useHeuristic(solver.modeHeuristic())
+}
+
+// These errors are returned by AddClause.
+var (
+	// ErrUnsatisfiable is returned once the clauses added so far have been
+	// found unsatisfiable at decision level 0.
+	ErrUnsatisfiable = errors.New("formula is unsatisfiable")
+	// ErrInvalidLiteral is returned for a literal whose variable is not one
+	// of the variables of the solver.
+	ErrInvalidLiteral = errors.New("literal of an unknown variable")
+	// ErrPreprocessed is returned once preprocessing has removed clauses or
+	// variables, which clauses added afterwards could bring back.
+	ErrPreprocessed = errors.New("clauses cannot be added after preprocessing")
+)
+
+// AddClause adds a clause of the original formula to the Solver, undoing all
+// assignments above decision level 0. The clause is normalized first: duplicate
+// literals and literals false at level 0 are removed, and satisfied or
+// tautological clauses are dropped. A unit clause is propagated right away. If
+// the clause is empty after normalization, or propagation conflicts, the solver
+// becomes permanently unsatisfiable and ErrUnsatisfiable is returned.
+func (solver *Solver) AddClause(lits []Lit) error {
+	switch {
+	case solver.unsat:
+		return ErrUnsatisfiable
+	case solver.preprocessed:
+		return ErrPreprocessed
+	}
+	for _, l := range lits {
+		if l == 0 || l.variable() > solver.NumVariables() {
+			return ErrInvalidLiteral
+		}
+	}
+	solver.cancelUntil(0)
+	marks := solver.addMarks
+	clause := solver.addLits[:0]
+	satisfied := false
+	for _, l := range lits {
+		v := l.variable()
+		switch {
+		case solver.litValue(l) == LTRUE || marks[v] == l.negation().polarity():
+			satisfied = true
+		case solver.litValue(l) == LFALSE || marks[v] == l.polarity():
+		default:
+			marks[v] = l.polarity()
+			clause = append(clause, l)
+		}
+	}
+	for _, l := range clause {
+		marks[l.variable()] = LNULL
+	}
+	solver.addLits = clause
+	if satisfied {
+		return nil
+	}
+	if len(clause) < len(lits) {
+		solver.proofAdd(clause)
+	}
+	if ok, _ := solver.attach(clause, false); !ok || solver.propagate() != noClause {
+		solver.unsatisfiable()
+		return ErrUnsatisfiable
+	}
+	if len(clause) > 1 {
+		for _, l := range clause {
+			solver.bumpLit(l)
 		}
 	}
+	return nil
+}
+
+// addLearnt adds a learnt clause and bumps its literals, returning the clause
+// of the arena if it has at least three literals.
+func (solver *Solver) addLearnt(lits []Lit) Clause {
+	_, clause := solver.attach(lits, true)
+	if len(lits) > 1 {
+		for i := 0; i < len(lits); i++ {
+			solver.bumpLit(lits[i])
+		}
+	}
+	return clause
+}
+
+// Okay reports whether the solver may still find the formula satisfiable, i.e.
+// whether no conflict has been found at decision level 0.
+func (solver *Solver) Okay() bool { return !solver.unsat }
+
+// unsatisfiable marks the solver as permanently unsatisfiable and returns
+// LFALSE. The empty clause is logged to the proof the first time only.
+func (solver *Solver) unsatisfiable() Lbool {
+	if !solver.unsat {
+		solver.unsat = true
+		solver.proofAdd(nil)
+	}
+	return LFALSE
+}
+
+// attach stores a clause in the solver, as a unit assignment, a binary clause
+// or a clause of the arena depending on its length, and returns false if the
+// clause is empty or a falsified unit.
+func (solver *Solver) attach(lits []Lit, learnt bool) (bool, Clause) {
 	if len(lits) == 0 {
-		return false, nil
+		return false, noClause
 	}
 	if len(lits) == 1 {
 		if learnt {
 			solver.stats.NumLearntUnit++
 		}
-		return solver.enqueue(lits[0], nil), nil
+		return solver.enqueue(lits[0], noClause), noClause
 	}
-	clause := &Clause{
-		lits:     lits,
-		learnt:   learnt,
-		activity: 0.0,
+	if len(lits) == 2 {
+		solver.addBinary(lits[0], lits[1], learnt)
+		return true, noClause
 	}
+	clause := solver.arena.alloc(lits, learnt)
 	if learnt {
 		solver.learntClauses = append(solver.learntClauses, clause)
 	} else {
 		solver.clauses = append(solver.clauses, clause)
 	}
-	solver.addWatcher(lits[0].negation(), clause)
-	solver.addWatcher(lits[1].negation(), clause)
-	for i := 0; i < len(lits); i++ {
-		solver.bumpLit(lits[i])
-	}
+	solver.addWatcher(lits[0].negation(), clause, lits[1])
+	solver.addWatcher(lits[1].negation(), clause, lits[0])
 	return true, clause
 }
 
 // Search will probe variable assignments until it either:
-//      i) Finds a satisfying assignment
-//      ii) Finds a conflict at the root level, meaning the formula is UNSAT
-//      iii) Reaches the conflict limit
+//
+//	i) Finds a satisfying assignment
+//	ii) Finds a conflict at the root level, meaning the formula is UNSAT
+//	iii) Reaches the conflict limit
+//
 // If the conflict limit is reached, no conclusion can be drawn about whether
 // the formula is satisfiable or not. In the case of (iii), Search can be
-// reinvoked until (i) or (ii) occur.
+// reinvoked until (i) or (ii) occur. If ModeInterval is set, the conflict limit
+// is replaced by the restart policy of the current mode, and the solver
+// switches between focused and stable mode at restarts. Once the formula has
+// been found unsatisfiable, every search returns LFALSE. The satisfying
+// assignment is given by Model.
+//
+// With Substitute, Unhide, Subsume, Block, Eliminate or AddVariables, the
+// first search preprocesses the formula, after which AddClause fails with
+// ErrPreprocessed. A search also gives up with LNULL once the memory limit is
+// exceeded or Interrupt is invoked, and Err then returns ErrMemoryLimit or
+// ErrInterrupted. Both are checked before preprocessing, so a search which
+// gives up right away still lets clauses be added. An interrupt during
+// preprocessing skips the remaining techniques, and AddClause fails as well.
+// After ErrMemoryLimit the search may be reinvoked with a higher limit, whereas
+// an interrupted solver gives up on every later search.
 func (solver *Solver) Search(params SolverParams) Lbool {
-	var conflict *Clause
+	var conflict Clause
 	var numConflicts int
 	solver.stats.NumRestarts++
+	solver.configure(params)
+	if solver.unsat {
+		return solver.unsatisfiable()
+	}
+	if !solver.checkMemory() || !solver.checkInterrupt() {
+		return LNULL
+	}
+	if (params.Substitute || params.Unhide || params.Subsume || params.Block || params.Eliminate || params.AddVariables) &&
+		!solver.preprocessed {
+		solver.preprocessed = true
+		if !solver.preprocess() {
+			return solver.unsatisfiable()
+		}
+	}
+	if !solver.inprocess() || !solver.importShared() {
+		return solver.unsatisfiable()
+	}
 	for {
 		conflict = solver.propagate()
-		if conflict != nil {
+		solver.brancher.propagated(conflict != noClause)
+		if conflict != noClause {
 			solver.stats.NumConflicts++
 			numConflicts++
-			if solver.DecisionLevel() == 0 {
-				return LFALSE
+			level := solver.conflictLevel(conflict)
+			if level == 0 {
+				return solver.unsatisfiable()
 			}
-			learnt, level := solver.analyze(conflict)
 			solver.cancelUntil(level)
-			solver.record(learnt)
-			solver.varActivityInc *= 1 / params.VarActivityDecay
+			solver.updatePhases(solver.trailDelim[level-1])
+			learnt, level := solver.analyze(conflict)
+			lbd := solver.computeLBD(learnt)
+			solver.lbdFast.update(float64(lbd))
+			solver.lbdSlow.update(float64(lbd))
+			solver.brancher.learnt(learnt)
+			solver.cancelUntil(solver.backjumpLevel(level))
+			solver.record(learnt, level, lbd)
+			solver.brancher.decay(params.VarActivityDecay)
 			solver.clauseActivityInc *= 1 / params.ClauseActivityDecay
 		} else {
-			if solver.DecisionLevel() == 0 {
-				solver.simplifyClauses(&solver.clauses)
-				solver.simplifyClauses(&solver.learntClauses)
+			if solver.DecisionLevel() == 0 && len(solver.trail) > solver.simplified {
+				if !solver.simplifyAll() {
+					return solver.unsatisfiable()
+				}
+				solver.simplified = len(solver.trail)
 			}
 			if len(solver.learntClauses) > params.MaxLearnts {
 				solver.trimLearnts()
 			}
-			if solver.NumAssigns() == solver.NumVariables() {
+			solver.collectGarbage()
+			if solver.stats.NumConflicts >= solver.memoryCheck {
+				solver.memoryCheck = solver.stats.NumConflicts + memoryCheckInterval
+				if !solver.checkMemory() {
+					return LNULL
+				}
+			}
+			if !solver.checkInterrupt() {
+				return LNULL
+			}
+			if solver.allAssigned() {
 				if solver.checkAsg() {
+					solver.extendModel()
 					return LTRUE
 				}
 				panic("invalid satisfying assignment detected through search")
 			}
-			if numConflicts > params.MaxConflict {
-				solver.cancelUntil(0)
+			if switchMode := solver.modeSwitchDue(); switchMode || solver.restartDue(numConflicts) {
+				solver.updatePhases(len(solver.trail))
+				if switchMode {
+					solver.cancelUntil(0)
+					solver.switchMode()
+				} else {
+					solver.cancelUntil(solver.restartLevel())
+					if solver.stable {
+						solver.lubyIndex++
+					}
+				}
 				return LNULL
 			}
-			solver.assume(solver.pickLit())
+			if params.RephaseInterval > 0 && solver.stats.NumConflicts >= solver.rephaseLimit {
+				solver.updatePhases(len(solver.trail))
+				solver.rephase()
+			}
+			solver.assume(solver.decisionLit(solver.pickLit()))
 			solver.stats.NumAssumptions++
 		}
 	}
 }
 
+// Solve searches until the formula is decided, restarting with the conflict
+// limit and the learnt clause limit of the parameters growing geometrically, or
+// with the learnt clause limit growing with the conflicts if ModeInterval is
+// set. It returns LNULL if a search gave up, with the reason given by Err.
+func (solver *Solver) Solve(params SolverParams) Lbool {
+	for {
+		res := solver.Search(params)
+		if res != LNULL || solver.err != nil {
+			return res
+		}
+		if params.ModeInterval > 0 {
+			// Restarts are frequent when switching modes, so the learnt
+			// clause limit grows with the conflicts rather than restarts.
+			params.MaxLearnts = solver.NumClauses()/3 + solver.stats.NumConflicts/10
+			continue
+		}
+		params.MaxConflict = int(float32(params.MaxConflict) * 1.1)
+		params.MaxLearnts = int(float32(params.MaxLearnts) * 1.5)
+	}
+}
+
+// configure applies the parameters of a search. The random number generator is
+// seeded on the first search only, where the variables are also shuffled and
+// the phases randomized if requested, so that every restart continues the same
+// pseudo random sequence.
+func (solver *Solver) configure(params SolverParams) {
+	solver.params = params
+	solver.err = nil
+	solver.model = nil
+	if solver.rng == nil {
+		solver.rng = createRandom(params.Seed)
+		solver.stable = params.Stable
+		if params.Shuffle {
+			solver.rng.shuffle(solver.varOrder)
+		}
+		if params.RandomInit {
+			for v := 1; v <= solver.NumVariables(); v++ {
+				solver.phases[v] = LFALSE
+				if solver.rng.float() < 0.5 {
+					solver.phases[v] = LTRUE
+				}
+			}
+		}
+		if params.Shuffle || params.RandomInit {
+			solver.branchers = [2]brancher{}
+		}
+	}
+	solver.useHeuristic(solver.modeHeuristic())
+}
+
+// preprocess simplifies the formula before the first search by equivalent
+// literal substitution, unhiding, subsumption, blocked clause elimination,
+// variable elimination and variable addition, as enabled by the parameters.
+// Once Interrupt is invoked the remaining techniques are skipped, and the
+// running one stops as if its budget was spent. It returns false if the
+// formula was found unsatisfiable.
+func (solver *Solver) preprocess() bool {
+	if solver.propagate() != noClause {
+		return false
+	}
+	if solver.params.Substitute && solver.checkInterrupt() && !solver.substitute(reduceBudget) {
+		return false
+	}
+	if solver.params.Unhide && solver.checkInterrupt() && !solver.unhide(unhideBudget) {
+		return false
+	}
+	if solver.params.Subsume && solver.checkInterrupt() && !solver.subsume(&solver.clauses, subsumeBudget) {
+		return false
+	}
+	if solver.params.Block && solver.checkInterrupt() && !solver.block() {
+		return false
+	}
+	if solver.params.Eliminate && solver.checkInterrupt() && !solver.eliminate(elimBudget) {
+		return false
+	}
+	if solver.params.AddVariables && solver.checkInterrupt() {
+		return solver.addVariables()
+	}
+	return true
+}
+
 // PrintModel should only be invoked when the solver has found a satisfying
 // assignment. When invoked it will print the satisfying assignment to stdout in
 // the DIMACS output format.
 func (solver *Solver) PrintModel() {
+	if solver.model == nil {
+		panic(fmt.Errorf("no satisfying assignment has been found"))
+	}
 	fmt.Print("v ")
-	for i := 1; i < len(solver.assignments); i++ {
-		switch solver.assignments[i] {
-		case LFALSE:
-			fmt.Printf("%d ", -1*i)
-		case LTRUE:
-			fmt.Printf("%d ", i)
-		case LNULL:
-			panic(fmt.Errorf("variable %d is unassigned", i))
-		}
+	for _, l := range solver.model {
+		fmt.Printf("%d ", l)
 	}
 	fmt.Print("0\n")
 }
@@ -186,6 +589,47 @@ func (solver *Solver) PrintStats() {
 	fmt.Println("c number of conflicts: ", solver.stats.NumConflicts)
 	fmt.Println("c number of assumptions: ", solver.stats.NumAssumptions)
 	fmt.Println("c number of learnt units: ", solver.stats.NumLearntUnit)
+	fmt.Println("c number of random decisions: ", solver.stats.NumRandom)
+	fmt.Println("c number of rephases: ", solver.stats.NumRephases)
+	fmt.Println("c number of chronological backtracks: ", solver.stats.NumChrono)
+	fmt.Println("c number of propagation ticks: ", solver.stats.NumTicks)
+	fmt.Println("c number of mode switches: ", solver.stats.NumModeSwitch)
+	fmt.Println("c number of reused trails: ", solver.stats.NumReuses)
+	fmt.Println("c number of reused decision levels: ", solver.stats.NumReusedLevel)
+	fmt.Println("c number of garbage collections: ", solver.stats.NumCollections)
+	fmt.Println("c number of memory reductions: ", solver.stats.NumMemoryReduce)
+	fmt.Println("c estimated memory usage in bytes: ", solver.stats.MemoryUsage)
+	fmt.Println("c number of eliminated variables: ", solver.stats.NumEliminated)
+	fmt.Println("c number of gate eliminations: ", solver.stats.NumGates)
+	fmt.Println("c number of subsumed clauses: ", solver.stats.NumSubsumed)
+	fmt.Println("c number of strengthened clauses: ", solver.stats.NumStrengthened)
+	fmt.Println("c number of probed literals: ", solver.stats.NumProbed)
+	fmt.Println("c number of failed literals: ", solver.stats.NumFailed)
+	fmt.Println("c number of units implied by both polarities: ", solver.stats.NumImplied)
+	fmt.Println("c number of hyper-binary resolvents: ", solver.stats.NumHyperBinary)
+	fmt.Println("c number of substituted variables: ", solver.stats.NumSubstituted)
+	fmt.Println("c number of transitively reduced binaries: ", solver.stats.NumReduced)
+	fmt.Println("c number of blocked clauses: ", solver.stats.NumBlocked)
+	fmt.Println("c number of covered clauses: ", solver.stats.NumCovered)
+	fmt.Println("c number of vivified clauses: ", solver.stats.NumVivified)
+	fmt.Println("c number of clauses shortened by vivification: ", solver.stats.NumVivifyShortened)
+	fmt.Println("c number of clauses removed by vivification: ", solver.stats.NumVivifyRemoved)
+	fmt.Println("c number of added variables: ", solver.stats.NumAdded)
+	fmt.Println("c number of clauses saved by variable addition: ", solver.stats.NumAddSaved)
+	fmt.Println("c number of hidden tautologies: ", solver.stats.NumHiddenTautologies)
+	fmt.Println("c number of hidden literals: ", solver.stats.NumHiddenLiterals)
+	fmt.Println("c number of failed literals found by unhiding: ", solver.stats.NumHiddenFailed)
+	fmt.Println("c number of exported clauses: ", solver.stats.NumExported)
+	fmt.Println("c number of imported clauses: ", solver.stats.NumImported)
+	fmt.Println("c number of exported units: ", solver.stats.NumUnitsExported)
+	fmt.Println("c number of imported units: ", solver.stats.NumUnitsImported)
+	fmt.Println("c number of dropped exports: ", solver.stats.NumShareDropped)
+	fmt.Println("c size of the clause arena in bytes: ", 4*len(solver.arena.mem))
+	for _, t := range techniques {
+		pass := t.stats(&solver.stats)
+		fmt.Printf("c %s: %d passes in %.3f s, %d simplifications\n",
+			t.name, pass.Passes, pass.Time.Seconds(), pass.Effect)
+	}
 }
 
 // DecisionLevel returns the current decision level of the solver.
@@ -195,7 +639,20 @@ func (solver *Solver) DecisionLevel() int { return len(solver.trailDelim) }
 func (solver *Solver) NumVariables() int { return len(solver.assignments) - 1 }
 
 // NumClauses returns the number of clauses in the formula.
-func (solver *Solver) NumClauses() int { return len(solver.clauses) }
+func (solver *Solver) NumClauses() int { return len(solver.clauses) + solver.numBinaries }
+
+// Err returns the reason why the last search gave up without an answer, or
+// nil if it did not.
+func (solver *Solver) Err() error { return solver.err }
+
+// Model returns the satisfying assignment found by the last search, as the
+// true literal of every variable in increasing order of variables, including
+// the variables removed by elimination but not the variables added by the
+// solver itself. It is nil unless the last search returned LTRUE.
+func (solver *Solver) Model() []Lit { return solver.model }
+
+// Stats returns the statistics of the solver.
+func (solver *Solver) Stats() SolverStats { return solver.stats }
 
 // NumLearnts returns the number of learnt clauses in the formula.
 func (solver *Solver) NumLearnts() int {
@@ -203,14 +660,7 @@ func (solver *Solver) NumLearnts() int {
 }
 
 // NumAssigns returns the number of assignments that have been made.
-func (solver *Solver) NumAssigns() (n int) {
-	for _, val := range solver.assignments[1:] {
-		if val != LNULL {
-			n++
-		}
-	}
-	return
-}
+func (solver *Solver) NumAssigns() int { return len(solver.trail) }
 
 // varValue returns the current assignment to the given variable.
 func (solver *Solver) varValue(variable int) Lbool {
@@ -229,35 +679,40 @@ func (solver *Solver) litValue(lit Lit) Lbool {
 	return LFALSE
 }
 
-// addWatcher adds a clause to the watch list of a literal.
-func (solver *Solver) addWatcher(lit Lit, clause *Clause) {
+// addWatcher adds a clause to the watch list of a literal, with the given
+// literal of the clause as blocking literal.
+func (solver *Solver) addWatcher(lit Lit, clause Clause, blocker Lit) {
 	i := lit.index()
-	solver.watcherLists[i] = append(solver.watcherLists[i], clause)
+	solver.watcherLists[i] = append(solver.watcherLists[i], watcher{clause, blocker})
 }
 
-// removeWatcher removes a clause from the watch list of a literal.
-func (solver *Solver) removeWatcher(lit Lit, clause *Clause) {
-	i := lit.index()
-	for j := 0; j < len(solver.watcherLists[i]); j++ {
-		if solver.watcherLists[i][j] == clause {
-			solver.watcherLists[i] = append(
-				solver.watcherLists[i][:j],
-				solver.watcherLists[i][j+1:]...,
-			)
-		}
+// addBinary adds the binary clause of the two literals to the implication lists
+// of their negations. Learnt binary clauses are never reduced, but they are
+// left out of the irredundant clauses.
+func (solver *Solver) addBinary(a, b Lit, learnt bool) {
+	solver.binaries[a.negation().index()] = append(solver.binaries[a.negation().index()], binary{b, learnt})
+	solver.binaries[b.negation().index()] = append(solver.binaries[b.negation().index()], binary{a, learnt})
+	if !learnt {
+		solver.numBinaries++
 	}
 }
 
-// clearWatchers removes and returns all clauses from the watcher list of the
-// given literal.
-func (solver *Solver) clearWatchers(lit Lit) (clauses []*Clause) {
-	clauses = solver.watcherLists[lit.index()]
-	solver.watcherLists[lit.index()] = []*Clause{}
-	return
+// enqueue adds a literal to the propagation queue, assigning it at the current
+// decision level.
+func (solver *Solver) enqueue(lit Lit, from Clause) bool {
+	return solver.enqueueAt(lit, from, solver.DecisionLevel())
+}
+
+// enqueueAt adds a literal to the propagation queue, assigning it at the given
+// decision level. The level may be below the current decision level when
+// backtracking chronologically.
+func (solver *Solver) enqueueAt(lit Lit, from Clause, level int) bool {
+	return solver.assign(lit, reason{clause: from}, level)
 }
 
-// enqueue adds a literal to the propagation queue.
-func (solver *Solver) enqueue(lit Lit, from *Clause) bool {
+// assign adds a literal with the given reason to the propagation queue, and
+// returns false if the literal is already false.
+func (solver *Solver) assign(lit Lit, from reason, level int) bool {
 	if solver.litValue(lit) != 0 {
 		if solver.litValue(lit) == LTRUE {
 			return true
@@ -265,176 +720,337 @@ func (solver *Solver) enqueue(lit Lit, from *Clause) bool {
 		return false
 	}
 	solver.assignments[lit.variable()] = lit.polarity()
-	solver.level[lit.variable()] = solver.DecisionLevel()
+	solver.level[lit.variable()] = level
 	solver.reasons[lit.variable()] = from
 	solver.trail = append(solver.trail, lit)
-	solver.propQueue = append(solver.propQueue, lit)
 	return true
 }
 
-// Removes and returns the front of the propagation queue.
+// Removes and returns the first trail literal which is not yet propagated.
 func (solver *Solver) dequeue() (lit Lit) {
-	lit = solver.propQueue[0]
-	solver.propQueue = solver.propQueue[1:]
+	lit = solver.trail[solver.qhead]
+	solver.qhead++
 	return
 }
 
-// undoOne undoes a single assignment.
-func (solver *Solver) undoOne() {
-	l := solver.trail[len(solver.trail)-1]
+// unassign undoes the assignment of a literal which has already been removed
+// from the trail.
+func (solver *Solver) unassign(l Lit) {
 	v := l.variable()
+	solver.phases[v] = solver.assignments[v]
 	solver.assignments[v] = LNULL
-	solver.reasons[v] = nil
+	solver.reasons[v] = reason{}
 	solver.level[v] = -1
-	solver.trail = solver.trail[:len(solver.trail)-1]
-	solver.variableOrder.insert(Lit(v))
-	solver.variableOrder.insert(Lit(-v))
+	solver.brancher.unassign(l)
 }
 
 // assume will force the given literal to be true by assigning its variable.
 func (solver *Solver) assume(lit Lit) bool {
 	solver.trailDelim = append(solver.trailDelim, len(solver.trail))
-	return solver.enqueue(lit, nil)
+	return solver.enqueue(lit, noClause)
 }
 
-// cancel will undo the most recent assumption and all assignments that followed
-// from unit propagation.
-func (solver *Solver) cancel() {
-	numDel := len(solver.trail) - solver.trailDelim[len(solver.trailDelim)-1]
-	for ; numDel > 0; numDel-- {
-		solver.undoOne()
+// cancelUntil undoes all assignments made above the given decision level. After
+// chronological backtracking the trail can contain literals assigned at or below
+// the given level beyond the start of the next level. Such literals are kept on
+// the trail, in order, and are propagated again.
+func (solver *Solver) cancelUntil(level int) {
+	if solver.DecisionLevel() <= level {
+		return
+	}
+	start := solver.trailDelim[level]
+	j := start
+	undone := solver.undone[:0]
+	for i := start; i < len(solver.trail); i++ {
+		l := solver.trail[i]
+		if solver.level[l.variable()] > level {
+			undone = append(undone, l)
+		} else {
+			solver.trail[j] = l
+			j++
+		}
+	}
+	solver.trail = solver.trail[:j]
+	if solver.qhead > start {
+		solver.qhead = start
 	}
-	solver.trailDelim = solver.trailDelim[:len(solver.trailDelim)-1]
+	solver.trailDelim = solver.trailDelim[:level]
+	for i := len(undone) - 1; i >= 0; i-- {
+		solver.unassign(undone[i])
+	}
+	solver.undone = undone
 }
 
-// cancel decisions until at the given decision level.
-func (solver *Solver) cancelUntil(level int) {
-	for solver.DecisionLevel() > level {
-		solver.cancel()
+// backjumpLevel returns the decision level to backtrack to after learning a
+// clause which is asserting at the given level. If chronological backtracking is
+// enabled and the jump would skip more than ChronoBacktrack levels, only the
+// current decision level is undone.
+func (solver *Solver) backjumpLevel(level int) int {
+	if level > 0 && solver.params.ChronoBacktrack > 0 &&
+		solver.DecisionLevel()-level > solver.params.ChronoBacktrack {
+		solver.stats.NumChrono++
+		return solver.DecisionLevel() - 1
+	}
+	return level
+}
+
+// restartLevel returns the decision level to backtrack to on a restart. If
+// trail reuse is enabled, the decision levels whose decisions have a higher
+// priority than the next decision of the brancher are kept, since a restart
+// would take the same decisions again.
+func (solver *Solver) restartLevel() (level int) {
+	if !solver.params.ReuseTrail || solver.allAssigned() {
+		return 0
 	}
+	next := solver.brancher.priority(solver.brancher.peek())
+	for level < solver.DecisionLevel() &&
+		solver.brancher.priority(solver.trail[solver.trailDelim[level]]) > next {
+		level++
+	}
+	if level > 0 {
+		solver.stats.NumReuses++
+		solver.stats.NumReusedLevel += level
+	}
+	return
 }
 
-// record adds a learnt clause.
-func (solver *Solver) record(lits []Lit) {
-	_, c := solver.AddClause(lits, true)
-	solver.enqueue(lits[0], c)
+// conflictLevel returns the highest decision level of a literal in the conflict
+// clause. Without chronological backtracking this is always the current level.
+func (solver *Solver) conflictLevel(confl Clause) (level int) {
+	for i := 0; i < solver.arena.size(confl); i++ {
+		if l := solver.arena.lit(confl, i); solver.level[l.variable()] > level {
+			level = solver.level[l.variable()]
+		}
+	}
+	return
 }
 
-// varActivityCmp compares the activity of two variables.
-func (solver *Solver) varActivityCmp(var1 int, var2 int) bool {
-	return solver.literalActivity[var1] < solver.literalActivity[var2]
+// record adds a learnt clause with the given LBD and assigns its first literal
+// at the level at which the clause is asserting.
+func (solver *Solver) record(lits []Lit, level int, lbd int) {
+	solver.proofAdd(lits)
+	solver.exportClause(lits, lbd)
+	c := solver.addLearnt(lits)
+	if c != noClause {
+		solver.arena.setLBD(c, lbd)
+	}
+	from := reason{clause: c}
+	if len(lits) == 2 {
+		from.other = lits[1]
+	}
+	solver.assign(lits[0], from, level)
+}
+
+// computeLBD returns the literal block distance of the given literals, i.e.
+// the number of distinct decision levels among them.
+func (solver *Solver) computeLBD(lits []Lit) (lbd int) {
+	solver.lbdStamp++
+	for _, l := range lits {
+		level := solver.level[l.variable()]
+		if solver.levelStamps[level] != solver.lbdStamp {
+			solver.levelStamps[level] = solver.lbdStamp
+			lbd++
+		}
+	}
+	return
 }
 
 // propagate invokes clause propagation for all watchers of each literal in the
 // queue until the queue is empty
-func (solver *Solver) propagate() *Clause {
-	for len(solver.propQueue) > 0 {
+func (solver *Solver) propagate() Clause {
+	for solver.qhead < len(solver.trail) {
 		l := solver.dequeue()
-		tmp := solver.clearWatchers(l)
-		for i := 0; i < len(tmp); i++ {
-			if !tmp[i].propagate(solver, l) {
-				for j := i + 1; j < len(tmp); j++ {
-					solver.addWatcher(l, tmp[j])
-				}
-				solver.propQueue = solver.propQueue[:0]
-				return tmp[i]
+		for _, b := range solver.binaries[l.index()] {
+			switch solver.litValue(b.lit) {
+			case LNULL:
+				solver.assign(b.lit, reason{other: l.negation()}, solver.level[l.variable()])
+			case LFALSE:
+				solver.arena.setLit(solver.binaryConflict, 0, b.lit)
+				solver.arena.setLit(solver.binaryConflict, 1, l.negation())
+				solver.qhead = len(solver.trail)
+				return solver.binaryConflict
 			}
 		}
+		ws := solver.watcherLists[l.index()]
+		solver.stats.NumTicks += len(ws)
+		j := 0
+		for i, w := range ws {
+			if solver.litValue(w.blocker) == LTRUE {
+				ws[j] = w
+				j++
+				continue
+			}
+			keep, ok := w.clause.propagate(solver, l)
+			if keep {
+				ws[j] = watcher{w.clause, solver.arena.lit(w.clause, 0)}
+				j++
+			}
+			if !ok {
+				j += copy(ws[j:], ws[i+1:])
+				solver.watcherLists[l.index()] = ws[:j]
+				solver.qhead = len(solver.trail)
+				return w.clause
+			}
+		}
+		solver.watcherLists[l.index()] = ws[:j]
 	}
-	return nil
+	return noClause
 }
 
 // analyze generates a learnt clause from the given conflict clause and the
-// state of the solver. It returns the learnt clause and the decision level at
-// which the learnt clauses becomes unit.
-func (solver *Solver) analyze(confl *Clause) (learnt []Lit, level int) {
-	learnt = []Lit{0}
-	var seen = make([]bool, solver.NumVariables()+1)
+// state of the solver, which must be at the decision level of the conflict. It
+// returns the learnt clause and the decision level at which the learnt clauses
+// becomes unit. The literal of the learnt clause with the highest level below
+// the conflict level is placed second. The learnt clause is built in scratch
+// space which is only valid until the next analysis.
+func (solver *Solver) analyze(confl Clause) (learnt []Lit, level int) {
+	learnt = append(solver.learnt[:0], 0)
+	var seen = solver.seen
 	var counter = 0
 	var p Lit = Lit(0)
-	var reason []Lit
+	var reasonLits = solver.reasonLits
+	var index = len(solver.trail) - 1
 	for {
-		if confl.learnt {
+		if solver.arena.learnt(confl) {
 			solver.bumpClause(confl)
 		}
-		for _, l := range confl.lits {
-			solver.bumpLit(l)
+		for i := 0; i < solver.arena.size(confl); i++ {
+			solver.bumpLit(solver.arena.lit(confl, i))
 		}
-		reason = confl.calcReason(p)
-		for j := 0; j < len(reason); j++ {
-			var q = reason[j]
+		reasonLits = confl.calcReason(solver, p, reasonLits[:0])
+		for j := 0; j < len(reasonLits); j++ {
+			var q = reasonLits[j]
 			if !seen[q.variable()] {
 				seen[q.variable()] = true
-				if solver.level[q.variable()] == solver.DecisionLevel() {
+				solver.analyzed = append(solver.analyzed, q.variable())
+				if solver.level[q.variable()] >= solver.DecisionLevel() {
 					counter++
 				} else if solver.level[q.variable()] > 0 {
 					learnt = append(learnt, q.negation())
 					if solver.level[q.variable()] > level {
 						level = solver.level[q.variable()]
+						learnt[1], learnt[len(learnt)-1] = learnt[len(learnt)-1], learnt[1]
 					}
 				}
 			}
 		}
 		for {
-			p = solver.trail[len(solver.trail)-1]
-			confl = solver.reasons[p.variable()]
-			solver.undoOne()
-			if seen[p.variable()] {
+			p = solver.trail[index]
+			index--
+			if seen[p.variable()] && solver.level[p.variable()] >= solver.DecisionLevel() {
 				break
 			}
 		}
+		confl = solver.reasonClause(p)
 		counter--
 		if counter < 1 {
 			break
 		}
 	}
 	learnt[0] = p.negation()
+	for _, v := range solver.analyzed {
+		seen[v] = false
+	}
+	solver.analyzed = solver.analyzed[:0]
+	solver.learnt = learnt
+	solver.reasonLits = reasonLits
 	return
 }
 
-// pickLit selects the highest activity unbound literal for assumption.
+// reasonClause returns the clause which implied the assigned literal. For
+// literals implied by a binary clause, the clause is built in scratch space
+// which is only valid until the next call.
+func (solver *Solver) reasonClause(lit Lit) Clause {
+	r := solver.reasons[lit.variable()]
+	if r.clause != noClause || r.other == 0 {
+		return r.clause
+	}
+	solver.arena.setLit(solver.binaryReason, 0, lit)
+	solver.arena.setLit(solver.binaryReason, 1, r.other)
+	return solver.binaryReason
+}
+
+// pickLit selects an unbound literal for assumption. With probability
+// RandomFreq a random variable is picked with its saved phase, otherwise the
+// decision heuristic is asked for a literal.
 func (solver *Solver) pickLit() Lit {
-	for {
-		l := solver.variableOrder.removeMax()
-		if solver.assignments[l.variable()] == LNULL {
-			return l
+	if solver.params.RandomFreq > 0 && solver.rng.float() < solver.params.RandomFreq {
+		v := solver.rng.intn(solver.NumVariables()) + 1
+		if solver.decidable(v) {
+			solver.stats.NumRandom++
+			return solver.phaseLit(v)
 		}
 	}
+	return solver.brancher.next()
 }
 
-// bumpVar increases the activity level of the given variable and rescales all
-// activities if necessary.
-func (solver *Solver) bumpLit(l Lit) {
-	solver.literalActivity[l.index()] += solver.varActivityInc
-	if solver.variableOrder.contains(l) {
-		solver.variableOrder.moveUp(l)
+// initialActivity returns the activity a brancher should initially give each
+// literal, which is a small random value if RandomInit is set and zero
+// otherwise.
+func (solver *Solver) initialActivity() float64 {
+	if solver.params.RandomInit && solver.rng != nil {
+		return solver.rng.float() * 1e-5
 	}
-	if solver.literalActivity[l.index()] > 1e100 {
-		for i := 0; i < len(solver.literalActivity); i++ {
-			solver.literalActivity[i] *= 1e-100
-		}
-		solver.varActivityInc *= 1e-100
+	return 0
+}
+
+// decidable reports whether the variable may be branched on, i.e. whether it is
+// unassigned and was not eliminated. Eliminated variables are never assigned,
+// as the model extension gives them their values.
+func (solver *Solver) decidable(v int) bool {
+	return solver.assignments[v] == LNULL && !solver.eliminated[v]
+}
+
+// allAssigned reports whether every variable which was not eliminated is
+// assigned.
+func (solver *Solver) allAssigned() bool {
+	return len(solver.trail)+solver.numEliminated == solver.NumVariables()
+}
+
+// phaseLit returns the literal of the given variable whose polarity matches the
+// value last assigned to the variable, defaulting to the negative literal.
+func (solver *Solver) phaseLit(v int) Lit {
+	if solver.phases[v] == LTRUE {
+		return Lit(v)
 	}
+	return Lit(-v)
+}
+
+// bumpLit notifies the decision heuristic that the literal took part in a
+// conflict.
+func (solver *Solver) bumpLit(l Lit) {
+	solver.brancher.bump(l)
 }
 
 // bumpClause increases the activity level of the given clause and rescales all
-// clause activities if necessary.
-func (solver *Solver) bumpClause(c *Clause) {
-	c.activity += solver.clauseActivityInc
-	if c.activity > 1e100 {
-		for i := 0; i < len(solver.learntClauses); i++ {
-			solver.learntClauses[i].activity *= 1e-100
+// clause activities if necessary. Activities are stored in single precision, so
+// they are rescaled well before they could overflow.
+func (solver *Solver) bumpClause(c Clause) {
+	a := &solver.arena
+	a.setActivity(c, a.activity(c)+float32(solver.clauseActivityInc))
+	if a.activity(c) > 1e20 {
+		for _, l := range solver.learntClauses {
+			a.setActivity(l, a.activity(l)*1e-20)
 		}
-		solver.clauseActivityInc *= 1e-100
+		solver.clauseActivityInc *= 1e-20
 	}
 }
 
 // checkAsg checks that the current assignment satisfies all clauses.
 func (solver *Solver) checkAsg() bool {
+	for v := 1; v <= solver.NumVariables(); v++ {
+		for _, l := range []Lit{Lit(v), Lit(-v)} {
+			for _, b := range solver.binaries[l.index()] {
+				if solver.litValue(l) == LTRUE && solver.litValue(b.lit) != LTRUE {
+					return false
+				}
+			}
+		}
+	}
 	for _, c := range solver.clauses {
 		violated := true
-		for _, l := range c.lits {
-			if solver.litValue(l) == LTRUE {
+		for i := 0; i < solver.arena.size(c); i++ {
+			if solver.litValue(solver.arena.lit(c, i)) == LTRUE {
 				violated = false
 				break
 			}
@@ -447,13 +1063,40 @@ func (solver *Solver) checkAsg() bool {
 }
 
 // trimLearnts removes the least active half of the learnt clauses from the
-// formula.
+// formula, except for clauses which are the reason of an assignment.
 func (solver *Solver) trimLearnts() {
 	solver.sortLearnts(0, len(solver.learntClauses)-1)
-	for i := 0; i < (len(solver.learntClauses) / 2); i++ {
-		solver.learntClauses[i].removeWatched(solver)
+	n := len(solver.learntClauses) / 2
+	j := 0
+	for i, c := range solver.learntClauses {
+		if i < n && !solver.locked(c) {
+			solver.deleteClause(c)
+		} else {
+			solver.learntClauses[j] = c
+			j++
+		}
 	}
-	solver.learntClauses = solver.learntClauses[len(solver.learntClauses)/2:]
+	solver.learntClauses = solver.learntClauses[:j]
+}
+
+// locked reports whether the clause is the reason of the assignment of its
+// first literal.
+func (solver *Solver) locked(c Clause) bool {
+	l := solver.arena.lit(c, 0)
+	return solver.reasons[l.variable()].clause == c && solver.litValue(l) == LTRUE
+}
+
+// deleteClause logs the deletion of the clause to the proof and frees it in the
+// clause arena. Its watchers are not searched for, but dropped by propagation
+// or by the next garbage collection once they are found. If the clause is the
+// reason of a literal, which only happens at decision level 0, the literal is
+// logged as a unit first so that the proof does not lose it.
+func (solver *Solver) deleteClause(c Clause) {
+	if solver.proof != nil && solver.locked(c) {
+		solver.proofAdd(solver.arena.appendLits(solver.proofLits[:0], c)[:1])
+	}
+	solver.proofDeleteClause(c)
+	solver.arena.free(c)
 }
 
 // sortLearnts will sort the learnt clauses in place according to their activity
@@ -473,7 +1116,7 @@ func (solver *Solver) partition(low, high int) int {
 	pivot := solver.learntClauses[high]
 	i := low
 	for j := low; j <= high; j++ {
-		if solver.learntClauses[j].activity < pivot.activity {
+		if solver.arena.activity(solver.learntClauses[j]) < solver.arena.activity(pivot) {
 			solver.learntClauses[i], solver.learntClauses[j] = // swap values
 				solver.learntClauses[j], solver.learntClauses[i]
 			i++
@@ -484,15 +1127,44 @@ func (solver *Solver) partition(low, high int) int {
 	return i
 }
 
-func (solver *Solver) simplifyClauses(clauses *[]*Clause) {
-	var j int
-	for i := 0; i < len(*clauses); i++ {
-		if (*clauses)[i].simplify(solver) {
-			(*clauses)[i].removeWatched(solver)
-		} else {
-			(*clauses)[j] = (*clauses)[i]
-			j++
+// simplifyClauses propagates and simplifies the given clauses at decision level
+// 0 until no new unit is found. It returns false if the formula was found
+// unsatisfiable.
+func (solver *Solver) simplifyClauses(clauses *[]Clause) bool {
+	for {
+		if solver.propagate() != noClause {
+			return false
+		}
+		assigns := len(solver.trail)
+		j := 0
+		for _, c := range *clauses {
+			removed, ok := c.simplify(solver)
+			if !ok {
+				return false
+			}
+			if !removed {
+				(*clauses)[j] = c
+				j++
+			}
+		}
+		*clauses = (*clauses)[:j]
+		if len(solver.trail) == assigns {
+			return true
+		}
+	}
+}
+
+// simplifyAll simplifies the irredundant and the learnt clauses at decision
+// level 0 until no new unit is found. It returns false if the formula was
+// found unsatisfiable.
+func (solver *Solver) simplifyAll() bool {
+	for {
+		assigns := len(solver.trail)
+		if !solver.simplifyClauses(&solver.clauses) || !solver.simplifyClauses(&solver.learntClauses) {
+			return false
+		}
+		if len(solver.trail) == assigns {
+			return true
 		}
 	}
-	*clauses = (*clauses)[:j]
 }
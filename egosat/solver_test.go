@@ -6,64 +6,99 @@ import (
 
 func TestAddClause(t *testing.T) {
 	solver := CreateSolver(10, 10)
-	if ok, _ := solver.AddClause([]Lit{}, false); ok != false {
-		t.Fail()
-	}
-	solver.AddClause([]Lit{1}, false)
+	solver.AddClause([]Lit{1})
 	if len(solver.clauses) > 0 {
 		t.Fail()
 	}
 	if solver.varValue(1) != LTRUE {
 		t.Fail()
 	}
-	if len(solver.propQueue) != 1 {
+	// Units are propagated right away
+	if solver.qhead != len(solver.trail) || solver.trail[0] != Lit(1) {
 		t.Fail()
 	}
-	if solver.propQueue[0] != Lit(1) {
-		t.Fail()
-	}
-	solver.AddClause([]Lit{-1, 2, 3}, false)
+	solver.AddClause([]Lit{-1, 2, 3, 4})
 	if len(solver.clauses) != 1 {
 		t.Fail()
 	}
-	for _, lit := range []Lit{1, -2} {
+	for _, lit := range []Lit{-2, -3} {
 		if len(solver.watcherLists[lit.index()]) != 1 {
 			t.Fail()
 		}
 	}
+	if err := solver.AddClause([]Lit{}); err != ErrUnsatisfiable || solver.Okay() {
+		t.Fail()
+	}
+	if err := solver.AddClause([]Lit{5, 6}); err != ErrUnsatisfiable {
+		t.Fail()
+	}
 }
 
-func TestAddWatcher(t *testing.T) {
-	solver := Solver{
-		clauses: []*Clause{
-			{lits: []Lit{1, 2, 3}},
-		},
-		watcherLists: make([][]*Clause, 6),
+func TestAddClauseNormalize(t *testing.T) {
+	solver := CreateSolver(10, 4)
+	if solver.AddClause([]Lit{1, 1, 2}) != nil || solver.NumClauses() != 1 {
+		t.Fail()
+	}
+	if len(solver.binaries[Lit(-1).index()]) != 1 {
+		t.Fail()
+	}
+	// Tautologies and satisfied clauses are dropped
+	solver.AddClause([]Lit{3, -4, -3})
+	solver.AddClause([]Lit{-2, -1})
+	solver.AddClause([]Lit{3})
+	solver.AddClause([]Lit{3, 4, 1})
+	if solver.NumClauses() != 2 {
+		t.Fail()
+	}
+	// False literals are removed, and the unit is propagated
+	if solver.AddClause([]Lit{-3, 1}) != nil || solver.varValue(2) != LFALSE {
+		t.Fail()
+	}
+	if solver.AddClause([]Lit{0, 1}) != ErrInvalidLiteral || solver.AddClause([]Lit{5}) != ErrInvalidLiteral {
+		t.Fail()
+	}
+	if solver.AddClause([]Lit{-1}) != ErrUnsatisfiable || solver.Okay() {
+		t.Fail()
 	}
-	solver.addWatcher(Lit(-2), solver.clauses[0])
+	params := SolverParams{MaxConflict: 10, MaxLearnts: 10, VarActivityDecay: 0.95, ClauseActivityDecay: 0.999}
+	if solver.Search(params) != LFALSE {
+		t.Fail()
+	}
+}
+
+func TestAddWatcher(t *testing.T) {
+	solver := Solver{arena: createArena(0), watcherLists: make([][]watcher, 6)}
+	solver.clauses = []Clause{solver.arena.alloc([]Lit{1, 2, 3}, false)}
+	solver.addWatcher(Lit(-2), solver.clauses[0], Lit(1))
 	if len(solver.watcherLists[Lit(-2).index()]) != 1 {
 		t.Fail()
 	}
-	if solver.watcherLists[Lit(-2).index()][0] != solver.clauses[0] {
+	if solver.watcherLists[Lit(-2).index()][0] != (watcher{solver.clauses[0], Lit(1)}) {
 		t.Fail()
 	}
 }
 
-func TestRemoveWatcher(t *testing.T) {
-	solver := Solver{clauses: []*Clause{{lits: []Lit{1, 2, 3}}}}
-	solver.watcherLists = [][]*Clause{
-		{},
-		{solver.clauses[0]},
-		{},
-		{solver.clauses[0]},
-		{},
-		{},
+func TestStaleWatcher(t *testing.T) {
+	solver := CreateSolver(2, 4)
+	solver.AddClause([]Lit{1, 2, 3})
+	solver.AddClause([]Lit{1, 2, 4})
+	if len(solver.watcherLists[Lit(-2).index()]) != 2 {
+		t.FailNow()
 	}
-	if len(solver.watcherLists[Lit(-2).index()]) != 1 {
+	// Deleting a clause leaves its watchers to propagation
+	solver.deleteClause(solver.clauses[0])
+	solver.clauses = solver.clauses[1:]
+	solver.assume(Lit(-2))
+	if solver.propagate() != noClause || len(solver.watcherLists[Lit(-2).index()]) != 0 {
+		t.Fail()
+	}
+	if len(solver.watcherLists[Lit(-4).index()]) != 1 || len(solver.watcherLists[Lit(-1).index()]) != 2 {
 		t.Fail()
 	}
-	solver.removeWatcher(Lit(-2), solver.clauses[0])
-	if len(solver.watcherLists[Lit(-2).index()]) != 0 {
+	// Garbage collection drops the watchers which were not visited
+	solver.cancelUntil(0)
+	solver.compactArena()
+	if len(solver.watcherLists[Lit(-1).index()]) != 1 {
 		t.Fail()
 	}
 }
@@ -98,14 +133,14 @@ func TestEnqueue(t *testing.T) {
 	nVars := 5
 	solver := &Solver{
 		assignments: make([]Lbool, nVars+1),
-		reasons:     make([]*Clause, nVars+1),
+		reasons:     make([]reason, nVars+1),
 		level:       make([]int, nVars+1),
 	}
-	solver.enqueue(2, nil)
-	if len(solver.propQueue) != 1 {
+	solver.enqueue(2, noClause)
+	if len(solver.trail)-solver.qhead != 1 {
 		t.Fail()
 	}
-	if solver.propQueue[0] != Lit(2) {
+	if solver.trail[solver.qhead] != Lit(2) {
 		t.Fail()
 	}
 	if solver.varValue(2) != LTRUE {
@@ -117,10 +152,10 @@ func TestEnqueue(t *testing.T) {
 	if solver.trail[0] != Lit(2) {
 		t.Fail()
 	}
-	if !solver.enqueue(2, nil) {
+	if !solver.enqueue(2, noClause) {
 		t.Fail()
 	}
-	if solver.enqueue(-2, nil) {
+	if solver.enqueue(-2, noClause) {
 		t.Fail()
 	}
 }
@@ -129,28 +164,28 @@ func TestDequeue(t *testing.T) {
 	nVars := 5
 	solver := &Solver{
 		assignments: make([]Lbool, nVars+1),
-		reasons:     make([]*Clause, nVars+1),
+		reasons:     make([]reason, nVars+1),
 		level:       make([]int, nVars+1),
 	}
-	solver.enqueue(2, nil)
-	if len(solver.propQueue) != 1 {
+	solver.enqueue(2, noClause)
+	if len(solver.trail)-solver.qhead != 1 {
 		t.Fail()
 	}
-	if solver.propQueue[0] != Lit(2) {
+	if solver.trail[solver.qhead] != Lit(2) {
 		t.Fail()
 	}
 	if solver.dequeue() != Lit(2) {
 		t.Fail()
 	}
-	if len(solver.propQueue) != 0 {
+	if len(solver.trail)-solver.qhead != 0 {
 		t.Fail()
 	}
 }
 
 func TestPropagate(t *testing.T) {
 	solver := CreateSolver(10, 10)
-	solver.AddClause([]Lit{-1, -2}, false)
-	solver.AddClause([]Lit{2, -3}, false)
+	solver.AddClause([]Lit{-1, -2})
+	solver.AddClause([]Lit{2, -3})
 	solver.assume(1)
 	solver.propagate()
 	if solver.varValue(2) != LFALSE {
@@ -161,14 +196,56 @@ func TestPropagate(t *testing.T) {
 	}
 }
 
+func TestPropagateBlocker(t *testing.T) {
+	solver := CreateSolver(10, 3)
+	solver.AddClause([]Lit{1, 2, 3})
+	solver.watcherLists[Lit(-1).index()][0].blocker = Lit(3)
+	solver.assume(3)
+	solver.assume(-1)
+	if solver.propagate() != noClause {
+		t.Fail()
+	}
+	// The clause is skipped without moving its watch
+	ws := solver.watcherLists[Lit(-1).index()]
+	if len(ws) != 1 || ws[0].blocker != Lit(3) || solver.arena.lit(solver.clauses[0], 0) != Lit(1) {
+		t.Fail()
+	}
+}
+
+func TestPropagateBinary(t *testing.T) {
+	solver := CreateSolver(10, 3)
+	solver.AddClause([]Lit{-1, 2})
+	solver.AddClause([]Lit{-2, 3})
+	if len(solver.clauses) != 0 || solver.NumClauses() != 2 {
+		t.Fail()
+	}
+	solver.assume(1)
+	if solver.propagate() != noClause || solver.varValue(3) != LTRUE {
+		t.Fail()
+	}
+	if r := solver.reasons[3]; r.clause != noClause || r.other != Lit(-2) {
+		t.Fail()
+	}
+	c := solver.reasonClause(Lit(3))
+	if solver.arena.lit(c, 0) != Lit(3) || solver.arena.lit(c, 1) != Lit(-2) {
+		t.Fail()
+	}
+	solver.cancelUntil(0)
+	solver.AddClause([]Lit{-1, -3})
+	solver.assume(1)
+	if confl := solver.propagate(); confl == noClause || solver.arena.size(confl) != 2 {
+		t.Fail()
+	}
+}
+
 func TestAnalyze(t *testing.T) {
 	solver := CreateSolver(10, 10)
-	solver.AddClause([]Lit{-1, -2}, false)
-	solver.AddClause([]Lit{2, -3}, false)
-	solver.AddClause([]Lit{-1, 2, 3}, false)
+	solver.AddClause([]Lit{-1, -2})
+	solver.AddClause([]Lit{2, -3})
+	solver.AddClause([]Lit{-1, 2, 3})
 	solver.assume(1)
 	confl := solver.propagate()
-	if confl == nil {
+	if confl == noClause {
 		t.Fail()
 	}
 	learnt, level := solver.analyze(confl)
@@ -185,10 +262,10 @@ func TestAnalyze(t *testing.T) {
 
 func TestSearch(t *testing.T) {
 	solver := CreateSolver(10, 10)
-	solver.AddClause([]Lit{1, 2}, false)
-	solver.AddClause([]Lit{-1, 2}, false)
-	solver.AddClause([]Lit{-1, -2}, false)
-	solver.AddClause([]Lit{1, -2}, false)
+	solver.AddClause([]Lit{1, 2})
+	solver.AddClause([]Lit{-1, 2})
+	solver.AddClause([]Lit{-1, -2})
+	solver.AddClause([]Lit{1, -2})
 	params := SolverParams{
 		MaxConflict:         100,
 		MaxLearnts:          100,
@@ -202,14 +279,14 @@ func TestSearch(t *testing.T) {
 
 func TestSortLearnts(t *testing.T) {
 	solver := CreateSolver(10, 10)
-	_, c1 := solver.AddClause([]Lit{1, 2, 3}, true)
-	c1.activity = 3.0
-	_, c2 := solver.AddClause([]Lit{1, 2, 3}, true)
-	c2.activity = 1.0
-	_, c3 := solver.AddClause([]Lit{1, 2, 3}, true)
-	c3.activity = 2.0
-	_, c4 := solver.AddClause([]Lit{1, 2, 3}, true)
-	c4.activity += 4.0
+	c1 := solver.addLearnt([]Lit{1, 2, 3})
+	solver.arena.setActivity(c1, 3.0)
+	c2 := solver.addLearnt([]Lit{1, 2, 3})
+	solver.arena.setActivity(c2, 1.0)
+	c3 := solver.addLearnt([]Lit{1, 2, 3})
+	solver.arena.setActivity(c3, 2.0)
+	c4 := solver.addLearnt([]Lit{1, 2, 3})
+	solver.arena.setActivity(c4, solver.arena.activity(c4)+4.0)
 	solver.sortLearnts(0, len(solver.learntClauses)-1)
 	if solver.learntClauses[0] != c2 {
 		t.Fail()
@@ -224,3 +301,218 @@ func TestSortLearnts(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSearchVMTF(t *testing.T) {
+	solver := CreateSolver(10, 3)
+	solver.AddClause([]Lit{1, 2})
+	solver.AddClause([]Lit{-1, 3})
+	solver.AddClause([]Lit{-2, -3})
+	params := SolverParams{
+		MaxConflict:         100,
+		MaxLearnts:          100,
+		VarActivityDecay:    0.95,
+		ClauseActivityDecay: 0.65,
+		Heuristic:           VMTF,
+	}
+	if solver.Search(params) != LTRUE {
+		t.Fail()
+	}
+	if !solver.checkAsg() {
+		t.Fail()
+	}
+}
+
+func TestSearchSeed(t *testing.T) {
+	run := func(seed uint64) (*Solver, Lbool) {
+		r := createRandom(1)
+		solver := CreateSolver(200, 50)
+		for i := 0; i < 200; i++ {
+			clause := make([]Lit, 3)
+			for j := range clause {
+				clause[j] = Lit(r.intn(50) + 1)
+				if r.float() < 0.5 {
+					clause[j] = clause[j].negation()
+				}
+			}
+			solver.AddClause(clause)
+		}
+		params := SolverParams{
+			MaxConflict:         1000,
+			MaxLearnts:          100,
+			VarActivityDecay:    0.95,
+			ClauseActivityDecay: 0.999,
+			Heuristic:           VMTF,
+			Seed:                seed,
+			RandomFreq:          0.1,
+			RandomInit:          true,
+			Shuffle:             true,
+		}
+		res := solver.Search(params)
+		for res == LNULL {
+			res = solver.Search(params)
+		}
+		return solver, res
+	}
+	s1, res1 := run(7)
+	s2, res2 := run(7)
+	if res1 != res2 || s1.stats != s2.stats || s1.stats.NumRandom == 0 {
+		t.Fail()
+	}
+	for v := range s1.assignments {
+		if s1.assignments[v] != s2.assignments[v] {
+			t.Fail()
+		}
+	}
+}
+
+func TestCancelUntilChrono(t *testing.T) {
+	solver := CreateSolver(10, 4)
+	solver.assume(1)
+	solver.assume(2)
+	solver.enqueueAt(3, noClause, 1)
+	solver.assume(4)
+	solver.qhead = len(solver.trail)
+	solver.cancelUntil(1)
+	if len(solver.trail) != 2 || solver.trail[0] != Lit(1) || solver.trail[1] != Lit(3) {
+		t.Fail()
+	}
+	if solver.qhead != 1 || solver.trail[solver.qhead] != Lit(3) {
+		t.Fail()
+	}
+	if solver.varValue(2) != LNULL || solver.varValue(4) != LNULL || solver.varValue(3) != LTRUE {
+		t.Fail()
+	}
+	if solver.DecisionLevel() != 1 || solver.level[3] != 1 {
+		t.Fail()
+	}
+}
+
+func TestBackjumpLevel(t *testing.T) {
+	solver := CreateSolver(10, 5)
+	for v := 1; v <= 5; v++ {
+		solver.assume(Lit(v))
+	}
+	if solver.backjumpLevel(1) != 1 {
+		t.Fail()
+	}
+	solver.params.ChronoBacktrack = 2
+	if solver.backjumpLevel(3) != 3 || solver.backjumpLevel(0) != 0 {
+		t.Fail()
+	}
+	if solver.backjumpLevel(1) != 4 || solver.stats.NumChrono != 1 {
+		t.Fail()
+	}
+}
+
+func TestSearchChrono(t *testing.T) {
+	solver := CreateSolver(10, 4)
+	solver.AddClause([]Lit{1, 2})
+	solver.AddClause([]Lit{-1, 2})
+	solver.AddClause([]Lit{-2, 3, 4})
+	solver.AddClause([]Lit{-2, -3, 4})
+	solver.AddClause([]Lit{-2, 3, -4})
+	solver.AddClause([]Lit{-2, -3, -4})
+	params := SolverParams{
+		MaxConflict:         100,
+		MaxLearnts:          100,
+		VarActivityDecay:    0.95,
+		ClauseActivityDecay: 0.999,
+		ChronoBacktrack:     1,
+	}
+	if solver.Search(params) != LFALSE {
+		t.Fail()
+	}
+}
+
+func TestRestartLevel(t *testing.T) {
+	solver := CreateSolver(10, 4)
+	solver.configure(SolverParams{Heuristic: VSIDS})
+	h := solver.brancher.(*vsids)
+	for _, l := range []Lit{1, 1, 1, 3, 3, 2} {
+		h.bump(l)
+	}
+	solver.assume(Lit(1))
+	solver.assume(Lit(2))
+	if solver.restartLevel() != 0 {
+		t.Fail()
+	}
+	// Only the decision of level 1 ranks above the next decision
+	solver.params.ReuseTrail = true
+	if solver.restartLevel() != 1 || solver.stats.NumReusedLevel != 1 {
+		t.Fail()
+	}
+	if solver.brancher.next() != Lit(3) {
+		t.Fail()
+	}
+}
+
+// createPigeonhole creates a solver for the unsatisfiable formula stating that
+// n+1 pigeons fit into n holes, which is hard for conflict driven search.
+func createPigeonhole(n int) *Solver {
+	solver := CreateSolver(n+1+n*n*(n+1)/2, n*(n+1))
+	pigeon := func(p, h int) Lit { return Lit(n*p + h + 1) }
+	for p := 0; p <= n; p++ {
+		clause := []Lit{}
+		for h := 0; h < n; h++ {
+			clause = append(clause, pigeon(p, h))
+		}
+		solver.AddClause(clause)
+	}
+	for h := 0; h < n; h++ {
+		for p := 0; p <= n; p++ {
+			for q := p + 1; q <= n; q++ {
+				solver.AddClause([]Lit{-pigeon(p, h), -pigeon(q, h)})
+			}
+		}
+	}
+	return solver
+}
+
+// warmSearch returns a search on a hard formula which has run long enough for
+// the scratch buffers, watch lists and clause arena to reach their working size.
+func warmSearch() func() {
+	solver := createPigeonhole(10)
+	params := SolverParams{
+		MaxConflict:         100,
+		MaxLearnts:          1000,
+		VarActivityDecay:    0.95,
+		ClauseActivityDecay: 0.999,
+		Heuristic:           VMTF,
+	}
+	search := func() {
+		if solver.Search(params) != LNULL {
+			panic("pigeonhole formula solved during warm up")
+		}
+	}
+	for i := 0; i < 200; i++ {
+		search()
+	}
+	return search
+}
+
+func TestSearchAllocs(t *testing.T) {
+	search := warmSearch()
+	if allocs := testing.AllocsPerRun(20, search); allocs != 0 {
+		t.Errorf("search allocates %v times per restart", allocs)
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	search := warmSearch()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		search()
+	}
+}
+
+func BenchmarkPropagate(b *testing.B) {
+	solver := createPigeonhole(10)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		solver.assume(Lit(i%solver.NumVariables() + 1))
+		solver.propagate()
+		solver.cancelUntil(0)
+	}
+}
@@ -0,0 +1,232 @@
+package egosat
+
+// These constants control equivalent literal substitution.
+const (
+	reduceBudget = 1 << 22 // Literals visited by transitive reduction before preprocessing gives up
+)
+
+// substitute finds the equivalent literals of the formula at decision level 0,
+// as the strongly connected components of the binary implication graph, and
+// replaces every literal of a component by its representative, the literal
+// with the smallest variable, in all irredundant clauses. Learnt clauses
+// containing replaced variables are dropped. A component containing a literal
+// and its negation makes the formula unsatisfiable. The equivalences are kept
+// for extending the model, and the binary clauses implied by other binary
+// clauses are removed, within the budget of literals visited. It returns false
+// if the formula was found unsatisfiable.
+func (solver *Solver) substitute(budget int) bool {
+	if solver.propagate() != noClause {
+		return false
+	}
+	repr, ok := solver.equivalences()
+	if !ok {
+		return false
+	}
+	if repr == nil {
+		return true
+	}
+	return solver.replaceEquivalent(repr, budget)
+}
+
+// replaceEquivalent replaces every literal by its representative, indexed by
+// Lit.index, in all irredundant clauses, keeps the equivalences for extending
+// the model and removes the binary clauses implied by other binary clauses
+// within the budget. It returns false if the formula was found unsatisfiable.
+func (solver *Solver) replaceEquivalent(repr []Lit, budget int) bool {
+	if !solver.simplifyAll() {
+		return false
+	}
+	clauses := solver.irredundant()
+	marks := make([]bool, 2*solver.NumVariables())
+	for i, c := range clauses {
+		changed := false
+		j := 0
+		taut := false
+		for _, l := range c {
+			r := repr[l.index()]
+			changed = changed || r != l
+			if marks[r.negation().index()] {
+				taut = true
+			} else if !marks[r.index()] {
+				marks[r.index()] = true
+				c[j] = r
+				j++
+			}
+		}
+		for _, l := range c[:j] {
+			marks[l.index()] = false
+		}
+		if taut {
+			clauses[i] = nil
+			continue
+		}
+		if changed {
+			solver.proofAdd(c[:j])
+		}
+		clauses[i] = c[:j]
+	}
+	solver.proofDeleteSubstituted(clauses, repr)
+	for v := 1; v <= solver.NumVariables(); v++ {
+		if r := repr[Lit(v).index()]; r != Lit(v) {
+			solver.eliminated[v] = true
+			solver.numEliminated++
+			solver.extension = append(solver.extension, []Lit{Lit(v), r.negation()}, []Lit{Lit(-v), r})
+			solver.stats.NumSubstituted++
+		}
+	}
+	solver.reduceBinaries(clauses, budget)
+	return solver.rebuild(clauses)
+}
+
+// proofDeleteSubstituted logs the deletion of the irredundant clauses which
+// contain substituted literals, once their substituted versions have been
+// added to the proof.
+func (solver *Solver) proofDeleteSubstituted(clauses [][]Lit, repr []Lit) {
+	if solver.proof == nil {
+		return
+	}
+	for _, c := range solver.irredundant() {
+		for _, l := range c {
+			if repr[l.index()] != l {
+				solver.proofDelete(c)
+				break
+			}
+		}
+	}
+}
+
+// equivalences returns the representative of every literal, indexed by
+// Lit.index, computed from the strongly connected components of the binary
+// implication graph by Tarjan's algorithm. It returns nil if no literal has
+// another representative, and false if a literal is equivalent to its
+// negation.
+func (solver *Solver) equivalences() ([]Lit, bool) {
+	n := 2 * solver.NumVariables()
+	repr := make([]Lit, n)
+	order := make([]int32, n) // Visit order of every literal plus one, 0 if unvisited
+	low := make([]int32, n)
+	onStack := make([]bool, n)
+	var stack []int
+	type frame struct{ node, edge int }
+	var calls []frame
+	var visited int32
+	found := false
+	for root := 0; root < n; root++ {
+		if order[root] != 0 || solver.litValue(indexLit(root)) != LNULL {
+			continue
+		}
+		calls = append(calls, frame{root, 0})
+		for len(calls) > 0 {
+			f := &calls[len(calls)-1]
+			u := f.node
+			if f.edge == 0 {
+				visited++
+				order[u], low[u] = visited, visited
+				stack = append(stack, u)
+				onStack[u] = true
+			}
+			succ := solver.binaries[u]
+			if f.edge < len(succ) {
+				w := succ[f.edge].lit.index()
+				f.edge++
+				if solver.litValue(indexLit(w)) != LNULL {
+					continue
+				}
+				if order[w] == 0 {
+					calls = append(calls, frame{w, 0})
+				} else if onStack[w] && order[w] < low[u] {
+					low[u] = order[w]
+				}
+				continue
+			}
+			calls = calls[:len(calls)-1]
+			if len(calls) > 0 {
+				if p := calls[len(calls)-1].node; low[u] < low[p] {
+					low[p] = low[u]
+				}
+			}
+			if low[u] != order[u] {
+				continue
+			}
+			i := len(stack) - 1
+			rep := indexLit(u)
+			for ; stack[i] != u; i-- {
+				if l := indexLit(stack[i]); l.variable() < rep.variable() {
+					rep = l
+				}
+			}
+			for _, w := range stack[i:] {
+				onStack[w] = false
+				if repr[w] == 0 {
+					repr[w] = rep
+					repr[w^1] = rep.negation()
+				} else if repr[w] != rep {
+					// The component of the negation was already found, and
+					// the literal is equivalent to its negation.
+					solver.proofAdd(append(solver.proofLits[:0], indexLit(w).negation()))
+					return nil, false
+				}
+				found = found || repr[w] != indexLit(w)
+			}
+			stack = stack[:i]
+		}
+	}
+	if !found {
+		return nil, true
+	}
+	for i := range repr {
+		if repr[i] == 0 {
+			repr[i] = indexLit(i)
+		}
+	}
+	return repr, true
+}
+
+// reduceBinaries removes the binary clauses implied by the other binary clauses
+// through the implication graph, i.e. those whose implication can be derived by
+// a path of at least two other implications.
+func (solver *Solver) reduceBinaries(clauses [][]Lit, budget int) {
+	n := 2 * solver.NumVariables()
+	succ := make([][]int, n) // Binary clauses implying a literal from each literal
+	for id, c := range clauses {
+		if len(c) == 2 {
+			succ[c[0].negation().index()] = append(succ[c[0].negation().index()], id)
+			succ[c[1].negation().index()] = append(succ[c[1].negation().index()], id)
+		}
+	}
+	stamp := make([]int, n)
+	var stack []Lit
+	for id, c := range clauses {
+		if len(c) != 2 || budget <= 0 || !solver.checkInterrupt() {
+			continue
+		}
+		from, to := c[0].negation(), c[1]
+		stack = append(stack[:0], from)
+		stamp[from.index()] = id + 1
+		reached := false
+		for len(stack) > 0 && !reached && budget > 0 {
+			u := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, e := range succ[u.index()] {
+				budget--
+				if e == id || clauses[e] == nil {
+					continue
+				}
+				w := other(clauses[e], u.negation())
+				if w == to {
+					reached = true
+					break
+				}
+				if stamp[w.index()] != id+1 {
+					stamp[w.index()] = id + 1
+					stack = append(stack, w)
+				}
+			}
+		}
+		if reached {
+			solver.proofDelete(c)
+			clauses[id] = nil
+			solver.stats.NumReduced++
+		}
+	}
+}
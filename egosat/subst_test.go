@@ -0,0 +1,70 @@
+package egosat
+
+import "testing"
+
+func TestEquivalences(t *testing.T) {
+	solver := CreateSolver(4, 4)
+	solver.AddClause([]Lit{-3, 2})
+	solver.AddClause([]Lit{-2, -4})
+	solver.AddClause([]Lit{4, 3})
+	solver.AddClause([]Lit{1, 2})
+	repr, ok := solver.equivalences()
+	if !ok || repr == nil {
+		t.FailNow()
+	}
+	// 2, 3 and -4 are equivalent, and 1 is not
+	if repr[Lit(3).index()] != 2 || repr[Lit(-4).index()] != 2 || repr[Lit(4).index()] != -2 {
+		t.Fail()
+	}
+	if repr[Lit(1).index()] != 1 || repr[Lit(-2).index()] != -2 {
+		t.Fail()
+	}
+}
+
+func TestEquivalencesContradiction(t *testing.T) {
+	solver := CreateSolver(3, 2)
+	solver.AddClause([]Lit{-1, 2})
+	solver.AddClause([]Lit{-2, -1})
+	solver.AddClause([]Lit{1, -2})
+	solver.AddClause([]Lit{2, 1})
+	if _, ok := solver.equivalences(); ok {
+		t.Fail()
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	solver := CreateSolver(4, 4)
+	solver.AddClause([]Lit{-1, 2})
+	solver.AddClause([]Lit{-2, 1})
+	solver.AddClause([]Lit{-1, 3, 4})
+	solver.AddClause([]Lit{-2, -3})
+	if !solver.substitute(reduceBudget) {
+		t.FailNow()
+	}
+	if solver.stats.NumSubstituted != 1 || !solver.eliminated[2] || solver.NumClauses() != 2 {
+		t.Fail()
+	}
+	params := SolverParams{
+		MaxConflict:         100,
+		MaxLearnts:          10,
+		VarActivityDecay:    0.95,
+		ClauseActivityDecay: 0.999,
+	}
+	if solver.Search(params) != LTRUE {
+		t.FailNow()
+	}
+	model := solver.Model()
+	// The substituted variable takes the value of its representative
+	if model[0].polarity() != model[1].polarity() || model[0] > 0 && (model[2] > 0 || model[3] < 0) {
+		t.Fail()
+	}
+}
+
+func TestReduceBinaries(t *testing.T) {
+	solver := CreateSolver(3, 3)
+	clauses := [][]Lit{{-1, 2}, {-1, 3}, {-2, 3}}
+	solver.reduceBinaries(clauses, reduceBudget)
+	if clauses[0] == nil || clauses[1] != nil || clauses[2] == nil || solver.stats.NumReduced != 1 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,197 @@
+package egosat
+
+import "sort"
+
+// These constants control the subsumption passes.
+const (
+	subsumeBudget = 1 << 24 // Literals visited by a pass before preprocessing gives up
+)
+
+// The subsumer struct holds the occurrence lists of a subsumption pass over a
+// list of clauses, which are the targets that may be subsumed or strengthened.
+type subsumer struct {
+	solver  *Solver
+	targets []Clause  // Clauses which may be subsumed or strengthened
+	sigs    []uint64  // Signatures of the targets
+	occurs  [][]int32 // Targets containing each literal, indexed by Lit.index
+	marks   []bool    // Marks literals of the candidate, indexed by Lit.index
+	lits    []Lit     // Scratch space for the literals of a candidate
+	budget  int       // Literals which may still be visited
+	unsat   bool      // Set when a clause is strengthened to a false unit or the empty clause
+}
+
+// signature returns a set of the variables of the literals, where a variable v
+// sets bit v mod 64. A clause can only be a subset of another clause, possibly
+// with a literal negated, if its signature is a subset of the other one.
+func signature(lits []Lit) (sig uint64) {
+	for _, l := range lits {
+		sig |= 1 << (uint(l.variable()) % 64)
+	}
+	return
+}
+
+// subsume runs backward subsumption and self-subsuming resolution at decision
+// level 0 over the given list of clauses. The binary clauses and the clauses of
+// the list, from the shortest, are candidates checked against the clauses of
+// the list containing their least frequent literal or its negation. A clause
+// containing all literals of a candidate is deleted, and a clause containing
+// all of them but one, which it contains negated, is strengthened by removing
+// that literal. Clauses strengthened to two literals become binary clauses,
+// and clauses strengthened to one literal become units, which are propagated
+// at the end of the pass. The pass gives up once the budget of literals
+// visited is spent. Learnt binary clauses are only candidates against learnt
+// clauses, as they may have been derived from the clauses they would subsume.
+// It returns false if the formula was found unsatisfiable.
+func (solver *Solver) subsume(list *[]Clause, budget int) bool {
+	if !solver.simplifyClauses(list) {
+		return false
+	}
+	learnt := list == &solver.learntClauses
+	s := solver.createSubsumer(*list)
+	s.budget = budget
+	for v := 1; v <= solver.NumVariables(); v++ {
+		for _, l := range []Lit{Lit(v), Lit(-v)} {
+			for _, b := range solver.binaries[l.index()] {
+				if (learnt || !b.learnt) && l.negation().index() < b.lit.index() && s.budget > 0 {
+					s.backward(append(s.lits[:0], l.negation(), b.lit), -1)
+				}
+			}
+		}
+	}
+	order := make([]int, len(s.targets))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return solver.arena.size(s.targets[order[i]]) < solver.arena.size(s.targets[order[j]])
+	})
+	for _, i := range order {
+		if s.budget <= 0 || !solver.checkInterrupt() {
+			break
+		}
+		if c := s.targets[i]; !solver.arena.deleted(c) {
+			s.backward(solver.arena.appendLits(s.lits[:0], c), i)
+		}
+	}
+	if s.unsat {
+		return false
+	}
+	j := 0
+	for _, c := range *list {
+		if !solver.arena.deleted(c) {
+			(*list)[j] = c
+			j++
+		}
+	}
+	*list = (*list)[:j]
+	return solver.propagate() == noClause
+}
+
+// createSubsumer builds the occurrence lists of the targets.
+func (solver *Solver) createSubsumer(targets []Clause) *subsumer {
+	s := &subsumer{
+		solver:  solver,
+		targets: append([]Clause(nil), targets...),
+		sigs:    make([]uint64, len(targets)),
+		occurs:  make([][]int32, 2*solver.NumVariables()),
+		marks:   make([]bool, 2*solver.NumVariables()),
+	}
+	for i, c := range s.targets {
+		s.lits = solver.arena.appendLits(s.lits[:0], c)
+		s.sigs[i] = signature(s.lits)
+		for _, l := range s.lits {
+			s.occurs[l.index()] = append(s.occurs[l.index()], int32(i))
+		}
+	}
+	return s
+}
+
+// backward subsumes or strengthens the targets with the candidate, which is
+// the target at index self or a binary clause if self is -1.
+func (s *subsumer) backward(lits []Lit, self int) {
+	best := lits[0]
+	for _, l := range lits[1:] {
+		if len(s.occurs[l.index()])+len(s.occurs[l.negation().index()]) <
+			len(s.occurs[best.index()])+len(s.occurs[best.negation().index()]) {
+			best = l
+		}
+	}
+	sig := signature(lits)
+	for _, l := range lits {
+		s.marks[l.index()] = true
+	}
+	a := &s.solver.arena
+	for _, l := range []Lit{best, best.negation()} {
+		for _, i := range s.occurs[l.index()] {
+			c := s.targets[i]
+			if int(i) == self || a.deleted(c) || a.size(c) < len(lits) || sig&^s.sigs[i] != 0 {
+				continue
+			}
+			s.budget -= a.size(c)
+			flip, ok := s.check(c, len(lits))
+			if !ok {
+				continue
+			}
+			if flip == 0 {
+				s.solver.deleteClause(c)
+				s.solver.stats.NumSubsumed++
+			} else {
+				s.unsat = s.unsat || !s.solver.strengthen(c, flip)
+				s.solver.stats.NumStrengthened++
+			}
+		}
+	}
+	for _, l := range lits {
+		s.marks[l.index()] = false
+	}
+}
+
+// check reports whether the marked candidate of n literals subsumes the clause,
+// or strengthens it by removing the returned literal. The literal is 0 if the
+// clause is subsumed.
+func (s *subsumer) check(c Clause, n int) (flip Lit, ok bool) {
+	a := &s.solver.arena
+	found := 0
+	for i := 0; i < a.size(c); i++ {
+		l := a.lit(c, i)
+		if s.marks[l.index()] {
+			found++
+		} else if s.marks[l.negation().index()] {
+			if flip != 0 {
+				return 0, false
+			}
+			flip = l
+			found++
+		}
+	}
+	return flip, found == n
+}
+
+// strengthen removes the literal from the clause, which must be at decision
+// level 0, and turns the clause into a binary clause or an enqueued unit if at
+// most two literals remain. It returns false if the clause became empty or its
+// unit is false.
+func (solver *Solver) strengthen(c Clause, lit Lit) bool {
+	a := &solver.arena
+	if solver.proof != nil {
+		solver.proofLits = solver.proofLits[:0]
+		for i := 0; i < a.size(c); i++ {
+			if a.lit(c, i) != lit {
+				solver.proofLits = append(solver.proofLits, a.lit(c, i))
+			}
+		}
+		solver.proofAdd(solver.proofLits)
+		solver.proofDeleteClause(c)
+	}
+	w0, w1 := a.lit(c, 0), a.lit(c, 1)
+	n := a.size(c)
+	for i := 0; i < n; i++ {
+		if a.lit(c, i) == lit {
+			a.setLit(c, i, a.lit(c, n-1))
+			break
+		}
+	}
+	a.shrink(c, n-1)
+	_, ok := c.detach(solver, w0, w1)
+	return ok
+}
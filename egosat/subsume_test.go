@@ -0,0 +1,64 @@
+package egosat
+
+import "testing"
+
+func TestSignature(t *testing.T) {
+	if signature([]Lit{1, -2}) != 6 || signature([]Lit{-65}) != 2 {
+		t.Fail()
+	}
+}
+
+func TestSubsume(t *testing.T) {
+	solver := CreateSolver(10, 10)
+	solver.AddClause([]Lit{1, 2, 3})
+	solver.AddClause([]Lit{1, 2, 3, 4})  // Subsumed by (1 2 3)
+	solver.AddClause([]Lit{-1, 2, 3, 5}) // Strengthened to (2 3 5)
+	solver.AddClause([]Lit{6, 7})
+	solver.AddClause([]Lit{6, 7, 8, 9}) // Subsumed by (6 7)
+	solver.AddClause([]Lit{-6, 7, 9})   // Strengthened to (7 9)
+	solver.subsume(&solver.clauses, subsumeBudget)
+	if solver.stats.NumSubsumed != 2 || solver.stats.NumStrengthened != 2 {
+		t.Fatal(solver.stats)
+	}
+	if len(solver.clauses) != 2 || solver.numBinaries != 2 {
+		t.Fail()
+	}
+	lits := solver.arena.appendLits(nil, solver.clauses[1])
+	if len(lits) != 3 || signature(lits) != signature([]Lit{2, 3, 5}) {
+		t.Fail()
+	}
+	if len(solver.binaries[Lit(-7).index()]) != 2 {
+		t.Fail()
+	}
+	// Stale watchers are dropped by garbage collection
+	solver.compactArena()
+	if len(solver.watcherLists[Lit(-5).index()]) != 1 || len(solver.watcherLists[Lit(1).index()]) != 0 {
+		t.Fail()
+	}
+}
+
+func TestStrengthenUnit(t *testing.T) {
+	solver := CreateSolver(1, 3)
+	// Clauses of two literals left in the arena, as before a simplification
+	var c [2]Clause
+	for i := range c {
+		c[i] = solver.arena.alloc([]Lit{1, 2}, false)
+		solver.clauses = append(solver.clauses, c[i])
+		solver.addWatcher(-1, c[i], 2)
+		solver.addWatcher(-2, c[i], 1)
+	}
+	if !solver.strengthen(c[0], 2) || solver.varValue(1) != LTRUE || !solver.arena.deleted(c[0]) {
+		t.FailNow()
+	}
+	if !solver.subsume(&solver.clauses, subsumeBudget) || len(solver.clauses) != 0 {
+		t.Fail()
+	}
+	solver.compactArena()
+	if len(solver.watcherLists[Lit(-2).index()]) != 0 || len(solver.watcherLists[Lit(-1).index()]) != 0 {
+		t.Fail()
+	}
+	solver.AddClause([]Lit{-1, 3, 2})
+	if solver.Search(SolverParams{MaxConflict: 10, Subsume: true}) != LTRUE {
+		t.Fail()
+	}
+}
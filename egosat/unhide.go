@@ -0,0 +1,333 @@
+package egosat
+
+import "sort"
+
+// These constants control unhiding.
+const (
+	unhideRounds = 3       // Rounds of stamping in different random orders
+	unhideBudget = 1 << 24 // Literals visited by a pass before preprocessing gives up
+)
+
+// The unhider struct holds the time stamps of a depth-first search over the
+// binary implication graph. A literal implies another literal if the interval
+// between its discovery and finish stamps contains the interval of the other
+// literal.
+type unhider struct {
+	solver  *Solver
+	dsc     []int  // Discovery stamp of each literal, 0 if unstamped, indexed by Lit.index
+	fin     []int  // Finish stamp of each literal, 0 while it is on the search path
+	low     []int  // Lowest discovery stamp reachable from each literal on the stack
+	onStack []bool // Marks literals whose component is not complete yet
+	stack   []int  // Literals whose component is not complete yet
+	stamp   int    // Last stamp given
+	repr    []Lit  // Representative of each literal, nil if no equivalence was found
+	units   []Lit  // Literals implied by their own negation
+	unsat   bool   // Set when a literal is equivalent to its negation
+	removed []bool // Marks literals removed from the clause being strengthened
+	pos     []Lit  // Scratch space for the literals of a clause sorted by stamps
+	neg     []Lit  // Scratch space for the negated literals of a clause sorted by stamps
+	budget  int    // Literals which may still be visited
+}
+
+// unhide runs unhiding at decision level 0. The binary implication graph of
+// the irredundant binary clauses is stamped by a depth-first search from its
+// roots, taken in random order. Learnt binary clauses are left out, as they
+// may have been derived from the clauses they would remove. A literal reached
+// while its negation is on the search path is implied by its negation and
+// becomes a unit. The long irredundant clauses containing two literals such
+// that the negation of one implies the other are hidden tautologies, which are
+// removed, and a literal implying another literal of its clause is hidden and
+// removed from it. Finally the literals of a strongly connected component are
+// equivalent and are substituted. The graph is stamped again in unhideRounds
+// different orders as long as the budget of literals visited lasts. It returns
+// false if the formula was found unsatisfiable.
+func (solver *Solver) unhide(budget int) bool {
+	if solver.propagate() != noClause {
+		return false
+	}
+	u := &unhider{solver: solver, budget: budget}
+	for round := 0; round < unhideRounds && u.budget > 0 && solver.checkInterrupt(); round++ {
+		u.stampAll()
+		if u.unsat {
+			return false
+		}
+		for _, l := range u.units {
+			if solver.litValue(l) == LTRUE {
+				continue
+			}
+			solver.stats.NumHiddenFailed++
+			solver.proofAdd(append(solver.proofLits[:0], l))
+			if !solver.enqueue(l, noClause) {
+				return false
+			}
+		}
+		if solver.propagate() != noClause || !u.simplify() {
+			return false
+		}
+		// Transitive reduction is left to substitution passes
+		if u.equivalences() && !solver.replaceEquivalent(u.repr, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// stampAll stamps the unassigned literals of the binary implication graph,
+// starting from the roots, which have no incoming edge, in random order, and
+// then from the other literals with outgoing edges.
+func (u *unhider) stampAll() {
+	solver := u.solver
+	n := 2 * solver.NumVariables()
+	u.dsc = make([]int, n)
+	u.fin = make([]int, n)
+	u.low = make([]int, n)
+	u.onStack = make([]bool, n)
+	u.stamp = 0
+	u.repr = nil
+	u.units = u.units[:0]
+	var roots, others []int
+	for i := 0; i < n; i++ {
+		if len(solver.binaries[i]) == 0 || solver.litValue(indexLit(i)) != LNULL {
+			continue
+		}
+		if len(solver.binaries[i^1]) == 0 {
+			roots = append(roots, i)
+		} else {
+			others = append(others, i)
+		}
+	}
+	solver.rng.shuffle(roots)
+	solver.rng.shuffle(others)
+	for _, i := range append(roots, others...) {
+		if u.dsc[i] == 0 && !u.unsat {
+			u.stampFrom(i)
+		}
+	}
+	if u.repr != nil {
+		for i := range u.repr {
+			if u.repr[i] == 0 {
+				u.repr[i] = indexLit(i)
+			}
+		}
+	}
+}
+
+// stampFrom stamps the literals reachable from the root by Tarjan's algorithm.
+// Every literal of a strongly connected component gets the stamps of the first
+// literal of the component which was discovered.
+func (u *unhider) stampFrom(root int) {
+	solver := u.solver
+	type frame struct{ node, edge int }
+	calls := []frame{{root, 0}}
+	for len(calls) > 0 {
+		f := &calls[len(calls)-1]
+		v := f.node
+		if f.edge == 0 {
+			u.stamp++
+			u.dsc[v], u.low[v] = u.stamp, u.stamp
+			u.stack = append(u.stack, v)
+			u.onStack[v] = true
+		}
+		succ := solver.binaries[v]
+		if f.edge < len(succ) {
+			e := succ[f.edge]
+			f.edge++
+			u.budget--
+			if e.learnt || solver.litValue(e.lit) != LNULL {
+				continue
+			}
+			w := e.lit.index()
+			if u.dsc[w^1] != 0 && u.fin[w^1] == 0 {
+				// The negation of w is on the search path and implies w
+				u.units = append(u.units, indexLit(w))
+			}
+			if u.dsc[w] == 0 {
+				calls = append(calls, frame{w, 0})
+			} else if u.onStack[w] && u.dsc[w] < u.low[v] {
+				u.low[v] = u.dsc[w]
+			}
+			continue
+		}
+		calls = calls[:len(calls)-1]
+		u.stamp++
+		u.fin[v] = u.stamp
+		if len(calls) > 0 {
+			if p := calls[len(calls)-1].node; u.low[v] < u.low[p] {
+				u.low[p] = u.low[v]
+			}
+		}
+		if u.low[v] == u.dsc[v] {
+			u.component(v)
+		}
+	}
+}
+
+// component pops the strongly connected component of the given literal, which
+// was discovered first, from the stack. Its literals get the stamps of that
+// literal, and the representative with the smallest variable.
+func (u *unhider) component(v int) {
+	i := len(u.stack) - 1
+	rep := indexLit(v)
+	for ; u.stack[i] != v; i-- {
+		if l := indexLit(u.stack[i]); l.variable() < rep.variable() {
+			rep = l
+		}
+	}
+	members := u.stack[i:]
+	u.stack = u.stack[:i]
+	for _, w := range members {
+		u.onStack[w] = false
+		u.dsc[w], u.fin[w] = u.dsc[v], u.fin[v]
+	}
+	if len(members) == 1 {
+		return
+	}
+	if u.repr == nil {
+		u.repr = make([]Lit, len(u.dsc))
+	}
+	for _, w := range members {
+		if u.repr[w] == 0 {
+			u.repr[w] = rep
+			u.repr[w^1] = rep.negation()
+		} else if u.repr[w] != rep {
+			// The component of the negation was already found, and the
+			// literal is equivalent to its negation.
+			u.solver.proofAdd(append(u.solver.proofLits[:0], indexLit(w).negation()))
+			u.unsat = true
+			return
+		}
+	}
+}
+
+// equivalences reports whether the strongly connected components give
+// equivalences between unassigned literals, after dropping the assigned
+// literals from repr.
+func (u *unhider) equivalences() bool {
+	if u.repr == nil {
+		return false
+	}
+	found := false
+	for i, r := range u.repr {
+		if u.solver.litValue(indexLit(i)) != LNULL || u.solver.litValue(r) != LNULL {
+			u.repr[i] = indexLit(i)
+		}
+		found = found || u.repr[i] != indexLit(i)
+	}
+	return found
+}
+
+// implies reports whether the stamps show that literal a implies literal b.
+func (u *unhider) implies(a, b Lit) bool {
+	return u.dsc[a.index()] < u.dsc[b.index()] && u.fin[b.index()] < u.fin[a.index()]
+}
+
+// simplify removes the hidden tautologies and the hidden literals of the long
+// irredundant clauses. It returns false if the formula was found
+// unsatisfiable.
+func (u *unhider) simplify() bool {
+	solver := u.solver
+	if !solver.simplifyAll() {
+		return false
+	}
+	u.removed = make([]bool, len(u.dsc))
+	clauses := solver.irredundant()
+	changed := false
+	for i, c := range clauses {
+		if len(c) < 3 || u.budget <= 0 {
+			continue
+		}
+		u.budget -= len(c)
+		if u.tautology(c) {
+			solver.stats.NumHiddenTautologies++
+			solver.proofDelete(c)
+			clauses[i] = nil
+			changed = true
+		} else if s := u.strengthen(c); len(s) < len(c) {
+			solver.stats.NumHiddenLiterals += len(c) - len(s)
+			solver.proofAdd(s)
+			solver.proofDelete(c)
+			clauses[i] = s
+			changed = true
+		}
+	}
+	if !changed {
+		return true
+	}
+	return solver.rebuild(clauses)
+}
+
+// sorted appends the literals of the clause which have not been removed,
+// negated if requested, to lits if they are stamped, in increasing order of
+// discovery stamps.
+func (u *unhider) sorted(lits []Lit, c []Lit, negate bool) []Lit {
+	for _, l := range c {
+		if u.removed[l.index()] {
+			continue
+		}
+		if negate {
+			l = l.negation()
+		}
+		if u.dsc[l.index()] != 0 {
+			lits = append(lits, l)
+		}
+	}
+	sort.Slice(lits, func(i, j int) bool { return u.dsc[lits[i].index()] < u.dsc[lits[j].index()] })
+	return lits
+}
+
+// tautology reports whether the clause contains two literals such that the
+// negation of the first one implies the second one, in which case the binary
+// clause of the two literals subsumes it. Both lists of literals are sorted by
+// discovery stamps, so that they are scanned once.
+func (u *unhider) tautology(c []Lit) bool {
+	u.pos = u.sorted(u.pos[:0], c, false)
+	u.neg = u.sorted(u.neg[:0], c, true)
+	for i, j := 0, 0; i < len(u.neg) && j < len(u.pos); {
+		switch a, b := u.neg[i].index(), u.pos[j].index(); {
+		case u.dsc[a] > u.dsc[b]:
+			j++
+		case u.fin[a] < u.fin[b]:
+			i++
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// strengthen returns the clause without its literals implying another of its
+// literals. Going through the literals by decreasing discovery stamps, a
+// literal whose interval contains the interval of the last literal kept
+// implies it, and going through the negated literals by increasing discovery
+// stamps, a negated literal whose interval is contained in the interval of the
+// last negated literal kept is implied by it.
+func (u *unhider) strengthen(c []Lit) []Lit {
+	u.pos = u.sorted(u.pos[:0], c, false)
+	for i, j := 0, len(u.pos)-1; i < j; i, j = i+1, j-1 {
+		u.pos[i], u.pos[j] = u.pos[j], u.pos[i]
+	}
+	for i, last := 1, 0; i < len(u.pos); i++ {
+		if u.implies(u.pos[i], u.pos[last]) {
+			u.removed[u.pos[i].index()] = true
+		} else {
+			last = i
+		}
+	}
+	u.neg = u.sorted(u.neg[:0], c, true)
+	for i, last := 1, 0; i < len(u.neg); i++ {
+		if u.implies(u.neg[last], u.neg[i]) {
+			u.removed[u.neg[i].negation().index()] = true
+		} else {
+			last = i
+		}
+	}
+	var s []Lit
+	for _, l := range c {
+		if u.removed[l.index()] {
+			u.removed[l.index()] = false
+		} else {
+			s = append(s, l)
+		}
+	}
+	return s
+}
@@ -0,0 +1,112 @@
+package egosat
+
+import "testing"
+
+func TestUnhideTautology(t *testing.T) {
+	solver := CreateSolver(3, 4)
+	solver.AddClause([]Lit{-1, 2})
+	solver.AddClause([]Lit{-2, 3})
+	solver.AddClause([]Lit{-1, 3, 4})
+	solver.configure(SolverParams{})
+	if !solver.unhide(unhideBudget) {
+		t.FailNow()
+	}
+	if solver.stats.NumHiddenTautologies != 1 || len(solver.clauses) != 0 {
+		t.Fail()
+	}
+}
+
+func TestUnhideLiteral(t *testing.T) {
+	solver := CreateSolver(3, 4)
+	solver.AddClause([]Lit{-1, 2})
+	solver.AddClause([]Lit{-2, 3})
+	solver.AddClause([]Lit{1, 3, 4})
+	solver.configure(SolverParams{})
+	if !solver.unhide(unhideBudget) {
+		t.FailNow()
+	}
+	if solver.stats.NumHiddenLiterals != 1 || len(solver.clauses) != 0 {
+		t.FailNow()
+	}
+	found := false
+	for _, l := range solver.binaries[Lit(-3).index()] {
+		found = found || l == binary{4, false}
+	}
+	if !found {
+		t.Fail()
+	}
+}
+
+func TestUnhideFailed(t *testing.T) {
+	solver := CreateSolver(2, 2)
+	solver.AddClause([]Lit{-1, 2})
+	solver.AddClause([]Lit{-2, -1})
+	solver.configure(SolverParams{})
+	if !solver.unhide(unhideBudget) {
+		t.FailNow()
+	}
+	if solver.stats.NumHiddenFailed != 1 || solver.varValue(1) != LFALSE {
+		t.Fail()
+	}
+}
+
+func TestUnhideEquivalence(t *testing.T) {
+	solver := CreateSolver(3, 3)
+	solver.AddClause([]Lit{-1, 2})
+	solver.AddClause([]Lit{-2, 1})
+	solver.AddClause([]Lit{1, -2, 3})
+	solver.AddClause([]Lit{-1, 2, -3})
+	solver.configure(SolverParams{})
+	if !solver.unhide(unhideBudget) {
+		t.FailNow()
+	}
+	if solver.stats.NumSubstituted != 1 || !solver.eliminated[2] {
+		t.Fail()
+	}
+}
+
+func TestUnhideModel(t *testing.T) {
+	r := createRandom(13)
+	for n := 0; n < 20; n++ {
+		var clauses [][]Lit
+		solver := CreateSolver(170, 50)
+		for i := 0; i < 170; i++ {
+			clause := make([]Lit, 3)
+			if i < 40 {
+				clause = clause[:2]
+			}
+			for j := range clause {
+				clause[j] = Lit(r.intn(50) + 1)
+				if r.float() < 0.5 {
+					clause[j] = clause[j].negation()
+				}
+			}
+			clauses = append(clauses, clause)
+			solver.AddClause(append([]Lit(nil), clause...))
+		}
+		params := SolverParams{
+			MaxConflict:         1000,
+			MaxLearnts:          100,
+			VarActivityDecay:    0.95,
+			ClauseActivityDecay: 0.999,
+			Unhide:              true,
+		}
+		res := solver.Search(params)
+		for res == LNULL {
+			res = solver.Search(params)
+		}
+		if res != LTRUE {
+			continue
+		}
+		model := solver.Model()
+		for _, c := range clauses {
+			satisfied := false
+			for _, l := range c {
+				satisfied = satisfied || model[l.variable()-1] == l
+			}
+			if !satisfied {
+				t.Fatalf("model falsifies %v", c)
+			}
+		}
+	}
+}
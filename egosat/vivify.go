@@ -0,0 +1,109 @@
+package egosat
+
+import "sort"
+
+// Learnt clauses are divided into tiers by their LBD. Core clauses are the most
+// useful ones, and tier2 clauses are still worth vivifying.
+const (
+	coreLBD  = 2 // Highest LBD of core clauses
+	tier2LBD = 6 // Highest LBD of tier2 clauses
+)
+
+// vivify runs a vivification pass over the learnt clauses of the core and tier2
+// tiers which have not been vivified yet, core clauses first, at decision level
+// 0. The pass stops once its propagation ticks exceed the budget. It returns
+// false if the formula was found unsatisfiable.
+func (solver *Solver) vivify(budget int) bool {
+	if solver.propagate() != noClause {
+		return false
+	}
+	a := &solver.arena
+	budget += solver.stats.NumTicks
+	candidates := solver.vivifyClauses[:0]
+	for _, c := range solver.learntClauses {
+		if a.lbd(c) <= tier2LBD && !a.vivified(c) && !solver.locked(c) {
+			candidates = append(candidates, c)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return a.lbd(candidates[i]) <= coreLBD && a.lbd(candidates[j]) > coreLBD
+	})
+	solver.vivifyClauses = candidates
+	saved := append(solver.probePhases[:0], solver.phases...)
+	ok := true
+	for _, c := range candidates {
+		if solver.stats.NumTicks >= budget || !solver.checkInterrupt() {
+			break
+		}
+		if !a.deleted(c) && !solver.vivifyClause(c) {
+			ok = false
+			break
+		}
+	}
+	copy(solver.phases, saved)
+	solver.probePhases = saved
+	j := 0
+	for _, c := range solver.learntClauses {
+		if !a.deleted(c) {
+			solver.learntClauses[j] = c
+			j++
+		}
+	}
+	solver.learntClauses = solver.learntClauses[:j]
+	return ok
+}
+
+// vivifyClause assumes the negations of the literals of the clause one at a
+// time and propagates them. If a literal is implied false, it is dropped from
+// the clause. If a conflict occurs, the remaining literals are dropped. If a
+// literal is implied true by another clause, the clause is implied by the
+// formula and is deleted. A shortened clause replaces the original one. It
+// returns false if the formula was found unsatisfiable.
+func (solver *Solver) vivifyClause(c Clause) bool {
+	a := &solver.arena
+	a.setVivified(c)
+	solver.stats.NumVivified++
+	lits := a.appendLits(solver.vivifyLits[:0], c)
+	kept := lits[len(lits):]
+	implied := false
+loop:
+	for _, l := range lits {
+		switch solver.litValue(l) {
+		case LFALSE:
+			continue
+		case LTRUE:
+			implied = solver.reasons[l.variable()].clause != c
+			kept = append(kept, l)
+			break loop
+		}
+		kept = append(kept, l)
+		solver.assume(l.negation())
+		if solver.propagate() != noClause {
+			break
+		}
+	}
+	solver.cancelUntil(0)
+	solver.vivifyLits = lits
+	if implied {
+		solver.stats.NumVivifyRemoved++
+		solver.deleteClause(c)
+		return true
+	}
+	if len(kept) == len(lits) {
+		return true
+	}
+	solver.stats.NumVivifyShortened++
+	lbd, activity := a.lbd(c), a.activity(c)
+	solver.proofAdd(kept)
+	solver.deleteClause(c)
+	ok, shortened := solver.attach(append([]Lit(nil), kept...), true)
+	if shortened != noClause {
+		if lbd > len(kept)-1 {
+			lbd = len(kept) - 1
+		}
+		a.setLBD(shortened, lbd)
+		a.setActivity(shortened, activity)
+		a.setVivified(shortened)
+	}
+	return ok && solver.propagate() == noClause
+}
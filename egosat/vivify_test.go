@@ -0,0 +1,36 @@
+package egosat
+
+import "testing"
+
+func TestVivifyShorten(t *testing.T) {
+	solver := CreateSolver(2, 4)
+	solver.AddClause([]Lit{1, -2})
+	solver.AddClause([]Lit{-3, 4, 2})
+	solver.addLearnt([]Lit{1, 2, 3})
+	if !solver.vivify(100) {
+		t.FailNow()
+	}
+	if solver.stats.NumVivifyShortened != 1 || len(solver.learntClauses) != 0 {
+		t.Fail()
+	}
+	found := false
+	for _, l := range solver.binaries[Lit(-1).index()] {
+		found = found || l == binary{3, true}
+	}
+	if !found || solver.DecisionLevel() != 0 {
+		t.Fail()
+	}
+}
+
+func TestVivifyRemove(t *testing.T) {
+	solver := CreateSolver(2, 3)
+	solver.AddClause([]Lit{1, 2})
+	solver.addLearnt([]Lit{1, 3})
+	solver.addLearnt([]Lit{1, 2, 3})
+	if !solver.vivify(100) {
+		t.FailNow()
+	}
+	if solver.stats.NumVivifyRemoved != 1 || len(solver.learntClauses) != 0 {
+		t.Fail()
+	}
+}
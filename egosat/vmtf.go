@@ -0,0 +1,145 @@
+package egosat
+
+import "sort"
+
+// The vmtf struct implements the Variable Move To Front decision heuristic.
+// Variables are kept in a doubly linked queue in which bumped variables are
+// moved to the back. Every move gives the variable a new timestamp, so the
+// relative position of two variables can be compared in constant time.
+// Decisions are taken from the back of the queue using the phase saved by the
+// solver.
+type vmtf struct {
+	noHooks
+	solver  *Solver  // Solver whose assignments and phases are consulted
+	prev    []int    // Previous variable in the queue, 0 if none
+	succ    []int    // Next variable in the queue, 0 if none
+	stamp   []uint64 // Timestamp of the last move of each variable
+	stamps  uint64   // Most recently issued timestamp
+	first   int      // Front of the queue, i.e. the least recently bumped
+	last    int      // Back of the queue, i.e. the most recently bumped
+	search  int      // Every variable after this one in the queue is assigned
+	bumped  []bool   // Marks variables which have been bumped in this conflict
+	pending []int    // Variables bumped in this conflict
+}
+
+// createVMTF creates a new VMTF brancher for the variables of the solver. The
+// variables are enqueued in the order of the solver, so that the last variable
+// is picked first.
+func createVMTF(solver *Solver) *vmtf {
+	nVars := solver.NumVariables()
+	h := &vmtf{
+		solver: solver,
+		prev:   make([]int, nVars+1),
+		succ:   make([]int, nVars+1),
+		stamp:  make([]uint64, nVars+1),
+		bumped: make([]bool, nVars+1),
+	}
+	for _, v := range solver.varOrder {
+		h.enqueue(v)
+	}
+	h.search = h.last
+	return h
+}
+
+// enqueue appends the variable to the back of the queue and gives it a new
+// timestamp.
+func (h *vmtf) enqueue(v int) {
+	h.prev[v] = h.last
+	h.succ[v] = 0
+	if h.last != 0 {
+		h.succ[h.last] = v
+	} else {
+		h.first = v
+	}
+	h.last = v
+	h.stamps++
+	h.stamp[v] = h.stamps
+}
+
+// dequeue unlinks the variable from the queue.
+func (h *vmtf) dequeue(v int) {
+	if h.search == v {
+		h.search = h.prev[v]
+	}
+	if h.prev[v] != 0 {
+		h.succ[h.prev[v]] = h.succ[v]
+	} else {
+		h.first = h.succ[v]
+	}
+	if h.succ[v] != 0 {
+		h.prev[h.succ[v]] = h.prev[v]
+	} else {
+		h.last = h.prev[v]
+	}
+}
+
+// bump records that the variable of the literal should be moved to the back of
+// the queue once the conflict has been analyzed.
+func (h *vmtf) bump(lit Lit) {
+	v := lit.variable()
+	if !h.bumped[v] {
+		h.bumped[v] = true
+		h.pending = append(h.pending, v)
+	}
+}
+
+// decay moves every variable bumped during the last conflict to the back of the
+// queue. The variables are moved in the order of their timestamps, so that
+// their relative order is preserved.
+func (h *vmtf) decay(factor float64) {
+	sort.Sort(h)
+	for _, v := range h.pending {
+		h.bumped[v] = false
+		if h.last == v {
+			continue
+		}
+		h.dequeue(v)
+		h.enqueue(v)
+		if h.solver.varValue(v) == LNULL {
+			h.search = v
+		}
+	}
+	h.pending = h.pending[:0]
+}
+
+// Len, Less and Swap let the variables bumped in this conflict be sorted by
+// their timestamps without allocating.
+func (h *vmtf) Len() int           { return len(h.pending) }
+func (h *vmtf) Less(i, j int) bool { return h.stamp[h.pending[i]] < h.stamp[h.pending[j]] }
+func (h *vmtf) Swap(i, j int)      { h.pending[i], h.pending[j] = h.pending[j], h.pending[i] }
+
+// unassign moves the search position to the variable of the literal if it lies
+// after the current search position.
+func (h *vmtf) unassign(lit Lit) {
+	v := lit.variable()
+	if h.stamp[v] > h.stamp[h.search] {
+		h.search = v
+	}
+}
+
+// activate moves the search position to the back of the queue.
+func (h *vmtf) activate() {
+	h.search = h.last
+}
+
+// peek returns the positive literal of the decidable variable closest to the
+// back of the queue.
+func (h *vmtf) peek() Lit {
+	v := h.search
+	for !h.solver.decidable(v) {
+		v = h.prev[v]
+	}
+	h.search = v
+	return Lit(v)
+}
+
+// priority returns the timestamp of the variable of the literal.
+func (h *vmtf) priority(lit Lit) float64 {
+	return float64(h.stamp[lit.variable()])
+}
+
+// next returns the decidable variable closest to the back of the queue, with
+// its saved phase.
+func (h *vmtf) next() Lit {
+	return h.solver.phaseLit(h.peek().variable())
+}
@@ -0,0 +1,38 @@
+package egosat
+
+import "testing"
+
+func TestVMTF(t *testing.T) {
+	solver := CreateSolver(10, 4)
+	solver.useHeuristic(VMTF)
+	h := solver.brancher.(*vmtf)
+	if h.first != 1 || h.last != 4 || h.search != 4 {
+		t.Fail()
+	}
+	// Bumped variables are moved to the back in the order of their timestamps
+	h.bump(Lit(-2))
+	h.bump(Lit(1))
+	h.bump(Lit(2))
+	h.decay(1)
+	if h.first != 3 || h.last != 2 || h.prev[2] != 1 || h.succ[3] != 4 {
+		t.Fail()
+	}
+	if len(h.pending) != 0 || h.bumped[1] || h.bumped[2] {
+		t.Fail()
+	}
+	// Decisions use the saved phase of the most recently bumped variable
+	solver.phases[2] = LTRUE
+	if l := h.next(); l != Lit(2) {
+		t.Fail()
+	}
+	solver.assume(Lit(2))
+	solver.assume(Lit(-1))
+	if l := h.next(); l != Lit(-4) {
+		t.Fail()
+	}
+	// Unassigned variables closer to the back become candidates again
+	solver.cancelUntil(0)
+	if h.search != 2 || solver.phases[1] != LFALSE {
+		t.Fail()
+	}
+}
@@ -0,0 +1,109 @@
+package egosat
+
+import "math"
+
+// The vsids struct implements the VSIDS decision heuristic. Activities are
+// kept per literal, so the literal returned by next also determines the phase of
+// the decision, unless RandomInit is set, in which case decisions take the saved
+// phases like the other heuristics.
+type vsids struct {
+	noHooks
+	solver   *Solver   // Solver whose assignments are consulted
+	activity []float64 // Activity of every literal
+	inc      float64   // Increment value for literal activities
+	order    *queue    // Priority queue of literals ordered by activity
+}
+
+// createVSIDS creates a new VSIDS brancher for the variables of the solver.
+func createVSIDS(solver *Solver) *vsids {
+	nVars := solver.NumVariables()
+	h := &vsids{
+		solver:   solver,
+		activity: make([]float64, 2*nVars),
+		inc:      1,
+	}
+	h.order = createQueue(h.activity, nVars)
+	for _, v := range solver.varOrder {
+		for _, l := range []Lit{Lit(v), Lit(-v)} {
+			h.activity[l.index()] = solver.initialActivity()
+			h.order.insert(l)
+		}
+	}
+	return h
+}
+
+// bump increases the activity of the given literal and rescales all activities
+// if necessary.
+func (h *vsids) bump(lit Lit) {
+	h.activity[lit.index()] += h.inc
+	if h.order.contains(lit) {
+		h.order.moveUp(lit)
+	}
+	if h.activity[lit.index()] > 1e100 {
+		for i := 0; i < len(h.activity); i++ {
+			h.activity[i] *= 1e-100
+		}
+		h.inc *= 1e-100
+	}
+}
+
+// decay increases the activity increment, which decays the activity of all
+// literals relative to future bumps.
+func (h *vsids) decay(factor float64) {
+	h.inc *= 1 / factor
+}
+
+// unassign returns both literals of the variable to the priority queue.
+func (h *vsids) unassign(lit Lit) {
+	for _, l := range []Lit{lit, lit.negation()} {
+		if !h.order.contains(l) {
+			h.order.insert(l)
+		}
+	}
+}
+
+// activate returns the literals of every decidable variable to the priority
+// queue.
+func (h *vsids) activate() {
+	for v := 1; v <= h.solver.NumVariables(); v++ {
+		if h.solver.decidable(v) {
+			h.unassign(Lit(v))
+		}
+	}
+}
+
+// peek returns the highest activity decidable literal, discarding assigned and
+// eliminated literals from the top of the priority queue.
+func (h *vsids) peek() Lit {
+	for {
+		l := h.order.max()
+		if h.solver.decidable(l.variable()) {
+			return l
+		}
+		h.order.removeMax()
+	}
+}
+
+// priority returns the activity of the literal. With RandomInit a decision
+// may take either phase, so the variable ranks by the higher activity of its
+// two literals.
+func (h *vsids) priority(lit Lit) float64 {
+	if h.solver.params.RandomInit {
+		return math.Max(h.activity[lit.index()], h.activity[lit.negation().index()])
+	}
+	return h.activity[lit.index()]
+}
+
+// next selects the highest activity decidable literal, with the saved phase of
+// its variable if RandomInit is set.
+func (h *vsids) next() Lit {
+	for {
+		l := h.order.removeMax()
+		if h.solver.decidable(l.variable()) {
+			if h.solver.params.RandomInit {
+				return h.solver.phaseLit(l.variable())
+			}
+			return l
+		}
+	}
+}
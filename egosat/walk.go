@@ -0,0 +1,190 @@
+package egosat
+
+import "math"
+
+// These constants control the local search used for rephasing.
+const (
+	walkFlipsPerClause = 10  // Flips allowed per original clause
+	walkCB             = 2.3 // Exponent of the ProbSAT break polynomial
+	walkEpsilon        = 1.0 // Offset of the ProbSAT break polynomial
+)
+
+// The walker struct holds the state of a ProbSAT local search over the original
+// and binary clauses which are not satisfied at decision level 0.
+type walker struct {
+	solver   *Solver
+	vals     []Lbool   // Current value of every variable
+	clauses  [][]Lit   // Clauses which are not satisfied at level 0
+	numTrue  []int     // Number of true literals of each clause
+	occurs   [][]int   // Clauses containing each literal, indexed by Lit.index
+	broken   []int     // Clauses without a true literal
+	position []int     // Position of each clause in broken, -1 if satisfied
+	flips    []int     // Variables flipped since the best assignment was seen
+	probs    []float64 // Scratch space for the probabilities of candidates
+}
+
+// walk runs a ProbSAT local search from the saved phases for at most maxFlips
+// flips, and saves the assignment which falsified the fewest clauses as the new
+// phases. It must be invoked at decision level 0, and variables assigned at
+// that level are never flipped.
+func (solver *Solver) walk(maxFlips int) {
+	w := solver.createWalker()
+	best := len(w.broken)
+	for i := 0; i < maxFlips && len(w.broken) > 0 && solver.checkInterrupt(); i++ {
+		v := w.pick(w.broken[solver.rng.intn(len(w.broken))])
+		w.flip(v)
+		w.flips = append(w.flips, v)
+		if len(w.broken) < best {
+			best = len(w.broken)
+			w.flips = w.flips[:0]
+		}
+	}
+	for i := len(w.flips) - 1; i >= 0; i-- {
+		w.flip(w.flips[i])
+	}
+	for v := 1; v <= solver.NumVariables(); v++ {
+		if solver.varValue(v) == LNULL {
+			solver.phases[v] = w.vals[v]
+		}
+	}
+}
+
+// createWalker initializes a local search from the saved phases.
+func (solver *Solver) createWalker() *walker {
+	nVars := solver.NumVariables()
+	w := &walker{
+		solver: solver,
+		vals:   make([]Lbool, nVars+1),
+		occurs: make([][]int, 2*nVars),
+	}
+	for v := 1; v <= nVars; v++ {
+		w.vals[v] = solver.varValue(v)
+		if w.vals[v] == LNULL {
+			w.vals[v] = solver.phaseLit(v).polarity()
+		}
+	}
+	for _, c := range solver.clauses {
+		w.addClause(solver.arena.appendLits(nil, c))
+	}
+	for v := 1; v <= nVars; v++ {
+		for _, l := range []Lit{Lit(v), Lit(-v)} {
+			for _, b := range solver.binaries[l.index()] {
+				if !b.learnt && l.negation().index() < b.lit.index() {
+					w.addClause([]Lit{l.negation(), b.lit})
+				}
+			}
+		}
+	}
+	return w
+}
+
+// addClause adds the clause to the local search unless it is satisfied at
+// decision level 0.
+func (w *walker) addClause(lits []Lit) {
+	for _, l := range lits {
+		if w.solver.litValue(l) == LTRUE {
+			return
+		}
+	}
+	id := len(w.clauses)
+	w.clauses = append(w.clauses, lits)
+	w.numTrue = append(w.numTrue, 0)
+	w.position = append(w.position, -1)
+	for _, l := range lits {
+		w.occurs[l.index()] = append(w.occurs[l.index()], id)
+		if w.value(l) == LTRUE {
+			w.numTrue[id]++
+		}
+	}
+	if w.numTrue[id] == 0 {
+		w.addBroken(id)
+	}
+}
+
+// value returns the value of the literal under the current assignment.
+func (w *walker) value(lit Lit) Lbool {
+	if w.vals[lit.variable()] == lit.polarity() {
+		return LTRUE
+	}
+	return LFALSE
+}
+
+// addBroken adds the clause to the list of falsified clauses.
+func (w *walker) addBroken(id int) {
+	w.position[id] = len(w.broken)
+	w.broken = append(w.broken, id)
+}
+
+// removeBroken removes the clause from the list of falsified clauses.
+func (w *walker) removeBroken(id int) {
+	last := w.broken[len(w.broken)-1]
+	w.broken[w.position[id]] = last
+	w.position[last] = w.position[id]
+	w.broken = w.broken[:len(w.broken)-1]
+	w.position[id] = -1
+}
+
+// breaks returns the number of clauses which would become falsified if the
+// variable was flipped.
+func (w *walker) breaks(v int) (n int) {
+	lit := Lit(v)
+	if w.vals[v] == LFALSE {
+		lit = lit.negation()
+	}
+	for _, id := range w.occurs[lit.index()] {
+		if w.numTrue[id] == 1 {
+			n++
+		}
+	}
+	return
+}
+
+// pick selects a variable of the falsified clause to flip, with a probability
+// decreasing polynomially in the number of clauses the flip would break.
+func (w *walker) pick(id int) int {
+	lits := w.clauses[id]
+	w.probs = w.probs[:0]
+	sum := 0.0
+	for _, l := range lits {
+		p := 0.0
+		if w.solver.varValue(l.variable()) == LNULL {
+			p = math.Pow(walkEpsilon+float64(w.breaks(l.variable())), -walkCB)
+		}
+		w.probs = append(w.probs, p)
+		sum += p
+	}
+	r := w.solver.rng.float() * sum
+	for i, p := range w.probs {
+		if r < p {
+			return lits[i].variable()
+		}
+		r -= p
+	}
+	for i := len(lits) - 1; i >= 0; i-- {
+		if w.probs[i] > 0 {
+			return lits[i].variable()
+		}
+	}
+	panic("falsified clause without unassigned variables during walk")
+}
+
+// flip inverts the value of the variable and updates the falsified clauses.
+func (w *walker) flip(v int) {
+	lit := Lit(v)
+	if w.vals[v] == LTRUE {
+		lit = lit.negation()
+	}
+	w.vals[v] = lit.polarity()
+	for _, id := range w.occurs[lit.index()] {
+		w.numTrue[id]++
+		if w.numTrue[id] == 1 {
+			w.removeBroken(id)
+		}
+	}
+	for _, id := range w.occurs[lit.negation().index()] {
+		w.numTrue[id]--
+		if w.numTrue[id] == 0 {
+			w.addBroken(id)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package egosat
+
+import "testing"
+
+func TestWalk(t *testing.T) {
+	solver := CreateSolver(10, 4)
+	solver.AddClause([]Lit{1, 2})
+	solver.AddClause([]Lit{-1, 3})
+	solver.AddClause([]Lit{-2, -3, 4})
+	solver.AddClause([]Lit{-4})
+	solver.propagate()
+	solver.configure(SolverParams{Seed: 3})
+	w := solver.createWalker()
+	// Clauses satisfied at level 0 are ignored and the first clause is broken
+	if len(w.clauses) != 3 || len(w.broken) != 1 {
+		t.Fail()
+	}
+	if lits := w.clauses[w.broken[0]]; len(lits) != 2 || lits[0] != 1 || lits[1] != 2 {
+		t.Fail()
+	}
+	if w.breaks(3) != 0 {
+		t.Fail()
+	}
+	solver.walk(100)
+	w = solver.createWalker()
+	if len(w.broken) != 0 || solver.phases[4] == LTRUE {
+		t.Fail()
+	}
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"io"
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/bcsherma/egosat/egosat"
 )
@@ -42,8 +44,11 @@ func parseClauses(reader *bufio.Reader, solver *egosat.Solver) {
 			}
 			newClause = append(newClause, egosat.Lit(litVal))
 		}
-		if ok, _ := solver.AddClause(newClause, false); !ok {
-			panic("Formula is trivially unsatisfiable!")
+		if err := solver.AddClause(newClause); err == egosat.ErrUnsatisfiable {
+			// The search reports the formula unsatisfiable right away
+			break
+		} else if err != nil {
+			panic(err)
 		}
 	}
 }
@@ -102,26 +107,178 @@ func parseProblemLine(reader *bufio.Reader) (nVars int, nClauses int) {
 	return
 }
 
+// heuristics maps the names accepted by the -heuristic flag to heuristics.
+var heuristics = map[string]egosat.Heuristic{
+	egosat.VSIDS.String(): egosat.VSIDS,
+	egosat.VMTF.String():  egosat.VMTF,
+	egosat.LRB.String():   egosat.LRB,
+	egosat.CHB.String():   egosat.CHB,
+}
+
 func main() {
-	solver := parseFormula(os.Args[1])
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "simplify":
+			simplifyCommand(os.Args[2:])
+			return
+		case "extend":
+			extendCommand(os.Args[2:])
+			return
+		}
+	}
+	heuristic := flag.String("heuristic", "vsids", "decision heuristic (vsids, vmtf, lrb or chb)")
+	seed := flag.Uint64("seed", 0, "seed of the pseudo random number generator")
+	randomFreq := flag.Float64("random-freq", 0, "probability of a random decision")
+	randomInit := flag.Bool("random-init", false, "start with random activities and phases")
+	shuffle := flag.Bool("shuffle", false, "randomly shuffle the initial variable order")
+	stable := flag.Bool("stable", false, "search in stable mode, following target phases")
+	rephase := flag.Int("rephase", 0, "base conflict interval between rephases, 0 disables")
+	chrono := flag.Int("chrono", 0, "backjumps longer than this backtrack one level, 0 disables")
+	modes := flag.Int("modes", 0, "conflicts before the first switch between focused and stable mode, 0 disables")
+	stableHeuristic := flag.String("stable-heuristic", "vsids", "decision heuristic of stable mode when switching modes")
+	lubyUnit := flag.Int("luby", 512, "conflicts per unit of the Luby restart sequence in stable mode")
+	reuseTrail := flag.Bool("reuse-trail", false, "keep the decisions a restart would take again")
+	elim := flag.Bool("elim", false, "run bounded variable elimination before searching and between restarts")
+	subsume := flag.Bool("subsume", false, "remove subsumed clauses and strengthen clauses by resolution")
+	probe := flag.Int("probe", 0, "least propagation ticks spent by a pass probing failed literals, 0 disables")
+	substitute := flag.Bool("substitute", false, "replace equivalent literals found in binary clauses")
+	block := flag.Bool("block", false, "remove blocked clauses before searching")
+	cover := flag.Bool("cover", false, "also remove covered clauses with -block")
+	vivify := flag.Bool("vivify", false, "vivify the learnt clauses with a low LBD between restarts")
+	unhide := flag.Bool("unhide", false, "remove hidden tautologies and hidden literals found in binary implications")
+	bva := flag.Bool("bva", false, "replace repeated clause patterns by fresh variables before searching")
+	proofFile := flag.String("proof", "", "write a DRAT proof to the given file")
+	memLimit := flag.Int("mem-limit", 0, "memory limit of the solver in megabytes, 0 disables")
+	workers := flag.Int("j", 1, "number of solvers of a parallel portfolio with different settings")
+	shareSize := flag.Int("share-size", 8, "longest learnt clause shared between the solvers of a portfolio, 0 disables sharing")
+	shareLBD := flag.Int("share-lbd", 2, "largest LBD of a learnt clause shared between the solvers of a portfolio")
+	flag.Parse()
+	h, ok := heuristics[*heuristic]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown decision heuristic %q\n", *heuristic)
+		os.Exit(1)
+	}
+	sh, ok := heuristics[*stableHeuristic]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown decision heuristic %q\n", *stableHeuristic)
+		os.Exit(1)
+	}
+	if *workers < 1 || *workers > 1 && *proofFile != "" {
+		fmt.Fprintln(os.Stderr, "-j must be positive, and 1 with -proof")
+		os.Exit(1)
+	}
+	solver := parseFormula(flag.Arg(0))
+	if *proofFile != "" {
+		f, err := os.Create(*proofFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		w := bufio.NewWriter(f)
+		defer f.Close()
+		defer w.Flush()
+		solver.SetProof(w)
+	}
 	params := egosat.SolverParams{
 		MaxConflict:         200,
 		MaxLearnts:          solver.NumClauses() / 3,
 		VarActivityDecay:    0.8,
 		ClauseActivityDecay: 0.999,
+		Heuristic:           h,
+		Seed:                *seed,
+		RandomFreq:          *randomFreq,
+		RandomInit:          *randomInit,
+		Shuffle:             *shuffle,
+		Stable:              *stable,
+		RephaseInterval:     *rephase,
+		ChronoBacktrack:     *chrono,
+		ModeInterval:        *modes,
+		StableHeuristic:     sh,
+		LubyUnit:            *lubyUnit,
+		ReuseTrail:          *reuseTrail,
+		MemoryLimit:         *memLimit << 20,
+		Eliminate:           *elim,
+		Subsume:             *subsume,
+		ProbeBudget:         *probe,
+		Substitute:          *substitute,
+		Block:               *block,
+		Cover:               *cover,
+		Vivify:              *vivify,
+		AddVariables:        *bva,
+		Unhide:              *unhide,
+		ShareSize:           *shareSize,
+		ShareLBD:            *shareLBD,
 	}
-	for {
-		res := solver.Search(params)
-		if res == egosat.LFALSE {
-			fmt.Println("s UNSATISFIABLE")
-			break
-		} else if res == egosat.LTRUE {
-			fmt.Println("s SATISFIABLE")
-			solver.PrintModel()
-			break
-		}
-		params.MaxConflict = int(float32(params.MaxConflict) * 1.1)
-		params.MaxLearnts = int(float32(params.MaxLearnts) * 1.5)
+	if *workers > 1 {
+		solvePortfolio(solver, egosat.PortfolioParams(params, *workers))
+		return
 	}
+	printAnswer(solver, solver.Solve(params))
 	solver.PrintStats()
 }
+
+// printAnswer prints the answer of the solver to the formula, with the model
+// if it is satisfiable, or the reason why the solver gave up.
+func printAnswer(solver *egosat.Solver, res egosat.Lbool) {
+	switch res {
+	case egosat.LFALSE:
+		fmt.Println("s UNSATISFIABLE")
+	case egosat.LTRUE:
+		fmt.Println("s SATISFIABLE")
+		solver.PrintModel()
+	default:
+		fmt.Println("s UNKNOWN")
+		if err := solver.Err(); err != nil {
+			fmt.Println("c", err)
+		}
+	}
+}
+
+// solvePortfolio solves the formula with a parallel portfolio, the first worker
+// reusing the parsed solver and the others parsing the formula again. It prints
+// the answer and the statistics of the worker which decided the formula, or of
+// the first worker if all of them gave up, followed by a line per worker.
+func solvePortfolio(solver *egosat.Solver, params []egosat.SolverParams) {
+	created := int32(0)
+	solvers, winner, res := egosat.SolvePortfolio(func() *egosat.Solver {
+		if atomic.AddInt32(&created, 1) == 1 {
+			return solver
+		}
+		return parseFormula(flag.Arg(0))
+	}, params)
+	if winner < 0 {
+		winner = 0
+	}
+	printAnswer(solvers[winner], res)
+	solvers[winner].PrintStats()
+	for i, s := range solvers {
+		p := params[i]
+		restarts := "geometric"
+		if p.ModeInterval > 0 {
+			restarts = "modes"
+		} else if p.Stable {
+			restarts = "stable"
+		}
+		phases := "saved"
+		if p.RandomInit {
+			phases = "random"
+		} else if p.RephaseInterval > 0 {
+			phases = "rephase"
+		}
+		// A worker stops either with an error or with the same answer
+		outcome := "unknown"
+		switch {
+		case s.Err() != nil:
+			outcome = s.Err().Error()
+		case res == egosat.LTRUE:
+			outcome = "satisfiable"
+		case res == egosat.LFALSE:
+			outcome = "unsatisfiable"
+		}
+		stats := s.Stats()
+		fmt.Printf("c worker %d: %s, %s restarts, %s phases, seed %d, %d conflicts, %d decisions, "+
+			"%d/%d clauses and %d/%d units exported/imported, %s\n",
+			i, p.Heuristic, restarts, phases, p.Seed, stats.NumConflicts, stats.NumAssumptions,
+			stats.NumExported, stats.NumImported, stats.NumUnitsExported, stats.NumUnitsImported, outcome)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bcsherma/egosat/egosat"
+)
+
+// solve runs the search loop of main on the solver until the formula is decided.
+func solve(solver *egosat.Solver) egosat.Lbool {
+	params := egosat.SolverParams{
+		MaxConflict:         200,
+		MaxLearnts:          solver.NumClauses() / 3,
+		VarActivityDecay:    0.8,
+		ClauseActivityDecay: 0.999,
+	}
+	return solver.Solve(params)
+}
+
+// BenchmarkSolve measures solving the test formula, excluding parsing, and
+// reports the propagation throughput in watched clauses visited per second.
+func BenchmarkSolve(b *testing.B) {
+	ticks := 0
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		solver := parseFormula("data/sat_formulae/test.cnf")
+		b.StartTimer()
+		if solve(solver) != egosat.LTRUE {
+			b.Fatal("test formula should be satisfiable")
+		}
+		ticks += solver.Stats().NumTicks
+	}
+	b.ReportMetric(float64(ticks)/b.Elapsed().Seconds(), "ticks/s")
+}